@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/cache"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/revocation"
+)
+
+const (
+	// localArticleCacheSize bounds the in-process article cache of the
+	// "memory" and "tiered" backends.
+	localArticleCacheSize = 10000
+	// localArticleCacheTTL is how long the "tiered" backend serves an
+	// article from process memory before asking Redis again.
+	localArticleCacheTTL = 10 * time.Second
+)
+
+// stores holds everything that keeps cached or short-lived state. kv is
+// shared by every instance and never evicts keys before they expire, so it
+// also holds the versions that retire entries of articleCache.
+type stores struct {
+	articleCache cache.Cache
+	kv           cache.Cache
+	sessions     repositories.SessionRepository
+	revocations  revocation.Store
+}
+
+// loadStores sets up the backend selected by CACHE_BACKEND:
+//
+//   - redis (default): everything in the Redis at REDIS_URL, which may point
+//     at a single node, Sentinel or Cluster (see cache.NewRedisClient).
+//   - tiered: like redis, with hot articles also kept in process memory.
+//   - memory: everything in process memory; for tests and single-instance
+//     development, REDIS_URL is not needed.
+func loadStores() (*stores, error) {
+	backend := os.Getenv("CACHE_BACKEND")
+	if backend == "" {
+		backend = "redis"
+	}
+	if backend == "memory" {
+		log.Println("Keeping cache, sessions and login state in process memory.")
+		return &stores{
+			articleCache: cache.NewLRU(localArticleCacheSize),
+			kv:           cache.NewLRU(0),
+			sessions:     repositories.NewMemorySessionRepo(),
+			revocations:  revocation.NewMemoryStore(revocation.DefaultRetention),
+		}, nil
+	}
+	if backend != "redis" && backend != "tiered" {
+		return nil, fmt.Errorf("CACHE_BACKEND must be redis, tiered or memory, got %q", backend)
+	}
+
+	redisURL := os.Getenv("REDIS_URL")
+	if redisURL == "" {
+		return nil, fmt.Errorf("REDIS_URL must be set for the %q cache backend", backend)
+	}
+	redisClient, err := cache.NewRedisClient(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	shared := cache.NewRedis(redisClient)
+	s := &stores{
+		articleCache: shared,
+		kv:           shared,
+		sessions:     repositories.NewRedisSessionRepo(redisClient),
+		revocations:  revocation.NewRedisStore(redisClient, revocation.DefaultRetention),
+	}
+	if backend == "tiered" {
+		s.articleCache = cache.NewTiered(cache.NewLRU(localArticleCacheSize), shared, localArticleCacheTTL)
+	}
+	return s, nil
+}
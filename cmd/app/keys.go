@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dhifanrazaqa/kumparan-article/pkg/signing"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
+)
+
+// loadAccessTokenKeys signs access tokens with the RSA or Ed25519 key in
+// JWT_PRIVATE_KEY_FILE when it is set, falling back to the shared JWT_SECRET.
+// Keys listed in JWT_PREVIOUS_KEY_FILES and JWT_PREVIOUS_SECRETS still verify
+// tokens until the retire time given with each entry.
+func loadAccessTokenKeys(jwtSecret string) (*signing.Keyring, error) {
+	var current *signing.Key
+	if path := os.Getenv("JWT_PRIVATE_KEY_FILE"); path != "" {
+		key, err := signing.LoadPrivateKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		current = key
+	} else {
+		current = signing.NewHMACKey([]byte(jwtSecret))
+	}
+
+	keys := signing.NewKeyring(current)
+	now := time.Now()
+	previousKeyFiles, err := retiringEntries("JWT_PREVIOUS_KEY_FILES", now, utils.AccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range previousKeyFiles {
+		key, err := signing.LoadPrivateKeyFile(entry.value)
+		if err != nil {
+			return nil, err
+		}
+		keys.Add(key, entry.retireAt)
+	}
+	previousSecrets, err := retiringEntries("JWT_PREVIOUS_SECRETS", now, utils.AccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range previousSecrets {
+		keys.Add(signing.NewHMACKey([]byte(entry.value)), entry.retireAt)
+	}
+	return keys, nil
+}
+
+// loadRefreshTokenKeys works like loadAccessTokenKeys for the refresh token
+// secret and REFRESH_TOKEN_PREVIOUS_SECRETS.
+func loadRefreshTokenKeys(refreshTokenSecret string) (*signing.Keyring, error) {
+	keys := signing.NewKeyring(signing.NewHMACKey([]byte(refreshTokenSecret)))
+	previousSecrets, err := retiringEntries("REFRESH_TOKEN_PREVIOUS_SECRETS", time.Now(), utils.RefreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range previousSecrets {
+		keys.Add(signing.NewHMACKey([]byte(entry.value)), entry.retireAt)
+	}
+	return keys, nil
+}
+
+// retiringEntry is a previous key or secret and the time it stops verifying
+// tokens.
+type retiringEntry struct {
+	value    string
+	retireAt time.Time
+}
+
+// retiringEntries parses the comma separated list in the environment variable
+// name. Entries already past their retire time are skipped with a warning, so
+// a restart after a rotation has finished does not need the configuration to
+// be cleaned up first.
+func retiringEntries(name string, now time.Time, ttl time.Duration) ([]retiringEntry, error) {
+	var entries []retiringEntry
+	for _, item := range splitList(os.Getenv(name)) {
+		entry, err := parseRetiringEntry(name, item, now, ttl)
+		if err != nil {
+			return nil, err
+		}
+		if !entry.retireAt.After(now) {
+			log.Printf("Skipping %s entry retired at %s; remove it from the list", name, entry.retireAt.Format(time.RFC3339))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// parseRetiringEntry splits a previous key entry of the form
+// "value@2025-01-02T15:04:05Z" into the key and the absolute time it stops
+// verifying tokens. The time is required so restarts never extend the life of
+// a retired key, and may not lie more than one token lifetime ahead since the
+// old key would then outlive every token it signed.
+func parseRetiringEntry(name, entry string, now time.Time, ttl time.Duration) (retiringEntry, error) {
+	i := strings.LastIndex(entry, "@")
+	if i < 0 {
+		return retiringEntry{}, fmt.Errorf("%s: entry must end with @<RFC 3339 retire time>", name)
+	}
+	retireAt, err := time.Parse(time.RFC3339, entry[i+1:])
+	if err != nil {
+		return retiringEntry{}, fmt.Errorf("%s: invalid retire time %q: %w", name, entry[i+1:], err)
+	}
+	if retireAt.After(now.Add(ttl)) {
+		return retiringEntry{}, fmt.Errorf("%s: retire time %s is more than %s away", name, retireAt.Format(time.RFC3339), ttl)
+	}
+	return retiringEntry{value: entry[:i], retireAt: retireAt}, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
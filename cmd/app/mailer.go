@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+
+	"github.com/dhifanrazaqa/kumparan-article/pkg/mailer"
+)
+
+// loadMailer delivers mail through SMTP_ADDR when it is set. Otherwise mail is
+// only written to MAIL_LOG_FILE, or to stdout, for local development.
+func loadMailer() (mailer.Mailer, error) {
+	if addr := os.Getenv("SMTP_ADDR"); addr != "" {
+		return mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Addr:     addr,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		})
+	}
+
+	if path := os.Getenv("MAIL_LOG_FILE"); path != "" {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		return mailer.NewLogMailer(f), nil
+	}
+	return mailer.NewLogMailer(os.Stdout), nil
+}
@@ -13,7 +13,7 @@ import (
 	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
 	"github.com/dhifanrazaqa/kumparan-article/internal/router"
 	"github.com/dhifanrazaqa/kumparan-article/internal/services"
-	"github.com/go-redis/redis"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/middleware"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 )
@@ -28,16 +28,29 @@ func main() {
 	loadEnv()
 
 	dbURL := os.Getenv("DATABASE_URL")
-	redisURL := os.Getenv("REDIS_URL")
 	port := os.Getenv("APP_PORT")
 	jwtSecret := os.Getenv("JWT_SECRET")
 	refreshTokenSecret := os.Getenv("REFRESH_TOKEN_SECRET")
+	requireVerifiedEmail := os.Getenv("REQUIRE_VERIFIED_EMAIL_TO_PUBLISH") == "true"
+
+	var articleCacheSoftTTL time.Duration
+	if v := os.Getenv("ARTICLE_CACHE_SOFT_TTL"); v != "" {
+		var err error
+		if articleCacheSoftTTL, err = time.ParseDuration(v); err != nil {
+			log.Fatalf("Invalid ARTICLE_CACHE_SOFT_TTL: %v", err)
+		}
+	}
+
+	trustedProxies, err := middleware.ParseTrustedProxies(splitList(os.Getenv("TRUSTED_PROXIES")))
+	if err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	if port == "" {
 		port = "8080"
 	}
-	if dbURL == "" || redisURL == "" {
-		log.Fatal("Error: DATABASE_URL dan REDIS_URL harus diatur")
+	if dbURL == "" {
+		log.Fatal("Error: DATABASE_URL harus diatur")
 	}
 
 	ctx := context.Background()
@@ -48,28 +61,89 @@ func main() {
 	}
 	defer dbPool.Close()
 
-	redisClient := redis.NewClient(&redis.Options{Addr: redisURL})
-	if _, err := redisClient.Ping().Result(); err != nil {
-		log.Fatalf("Failed to connect to Redis: %v", err)
+	stores, err := loadStores()
+	if err != nil {
+		log.Fatalf("Failed to set up cache backend: %v", err)
 	}
-	log.Println("Successfully connected to Database and Redis.")
+	log.Println("Successfully connected to Database and cache backend.")
+
+	accessTokenKeys, err := loadAccessTokenKeys(jwtSecret)
+	if err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+	log.Printf("Signing access tokens with %s key %s", accessTokenKeys.Current().Algorithm(), accessTokenKeys.Current().ID)
+	refreshTokenKeys, err := loadRefreshTokenKeys(refreshTokenSecret)
+	if err != nil {
+		log.Fatalf("Failed to load refresh token keys: %v", err)
+	}
+
+	mail, err := loadMailer()
+	if err != nil {
+		log.Fatalf("Failed to set up mailer: %v", err)
+	}
+
+	oidcProviders, err := loadOIDCProviders()
+	if err != nil {
+		log.Fatalf("Failed to configure OIDC providers: %v", err)
+	}
+
+	tokenRevocations := stores.revocations
 
 	userRepo := repositories.NewPgxUserRepo(dbPool)
-	userService := services.NewUserService(userRepo)
+	sessionRepo := stores.sessions
+	loginAttemptRepo := repositories.NewLoginAttemptRepo(stores.kv)
+	twoFactorRepo := repositories.NewPgxTwoFactorRepo(dbPool)
+	emailVerificationRepo := repositories.NewPgxEmailVerificationRepo(dbPool)
+	apiKeyRepo := repositories.NewPgxAPIKeyRepo(dbPool)
+	userService := services.NewUserService(userRepo, emailVerificationRepo, sessionRepo, apiKeyRepo, mail, tokenRevocations)
 	userHandler := handlers.NewUserHandler(userService)
 
-	authService := services.NewAuthService(userRepo, jwtSecret, refreshTokenSecret, redisClient)
+	authService := services.NewAuthService(userRepo, sessionRepo, apiKeyRepo, twoFactorRepo, loginAttemptRepo, accessTokenKeys, refreshTokenKeys, tokenRevocations)
 	authHandler := handlers.NewAuthHandler(authService)
+	jwksHandler := handlers.NewJWKSHandler(accessTokenKeys)
+
+	oidcStateRepo := repositories.NewOIDCStateRepo(stores.kv)
+	externalIdentityRepo := repositories.NewPgxExternalIdentityRepo(dbPool)
+	oidcService := services.NewOIDCService(oidcProviders, oidcStateRepo, externalIdentityRepo, userRepo, authService)
+	oidcHandler := handlers.NewOIDCHandler(oidcService)
+
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+
+	twoFactorService := services.NewTwoFactorService(twoFactorRepo, userRepo, loginAttemptRepo)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+
+	passwordResetRepo := repositories.NewPgxPasswordResetRepo(dbPool)
+	passwordResetService := services.NewPasswordResetService(userRepo, passwordResetRepo, sessionRepo, apiKeyRepo, mail, tokenRevocations)
+	passwordResetHandler := handlers.NewPasswordResetHandler(passwordResetService)
 
 	articleRepo := repositories.NewPgxArticleRepo(dbPool)
-	articleService := services.NewArticleService(articleRepo, redisClient)
+	articleService := services.NewArticleService(articleRepo, stores.articleCache, stores.kv, userRepo, requireVerifiedEmail, articleCacheSoftTTL)
 	articleHandler := handlers.NewArticleHandler(articleService)
 
+	tagRepo := repositories.NewPgxTagRepo(dbPool)
+	tagService := services.NewTagService(tagRepo)
+	tagHandler := handlers.NewTagHandler(tagService)
+
+	commentRepo := repositories.NewPgxCommentRepo(dbPool)
+	commentService := services.NewCommentService(commentRepo, articleRepo)
+	commentHandler := handlers.NewCommentHandler(commentService)
+
 	routerDeps := router.Deps{
-		UserHandler: userHandler,
-		AuthHandler: authHandler,
-		ArticleHandler: articleHandler,
-		JWTSecret: jwtSecret,
+		UserHandler:      userHandler,
+		AuthHandler:      authHandler,
+		ArticleHandler:   articleHandler,
+		TagHandler:       tagHandler,
+		CommentHandler:   commentHandler,
+		JWKSHandler:      jwksHandler,
+		TwoFactorHandler: twoFactorHandler,
+		PasswordHandler:  passwordResetHandler,
+		OIDCHandler:      oidcHandler,
+		APIKeyHandler:    apiKeyHandler,
+		TokenVerifier:    accessTokenKeys,
+		Revocations:      tokenRevocations,
+		APIKeys:          apiKeyService,
+		TrustedProxies:   trustedProxies,
 	}
 
 	mainRouter := router.SetupRouter(routerDeps)
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"io"
 	"log"
 	"os"
 	"testing"
@@ -10,7 +11,11 @@ import (
 	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
 	"github.com/dhifanrazaqa/kumparan-article/internal/router"
 	"github.com/dhifanrazaqa/kumparan-article/internal/services"
-	"github.com/go-redis/redis"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/cache"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/mailer"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/oidc"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/revocation"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/signing"
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
@@ -35,37 +40,63 @@ func TestMain(m *testing.M) {
 	}
 	defer testDbPool.Close()
 
-	redisURL := os.Getenv("REDIS_URL")
-	if redisURL == "" {
-		redisURL = "localhost:6379"
-	}
-	redisClient := redis.NewClient(&redis.Options{Addr: redisURL})
-	if _, err := redisClient.Ping().Result(); err != nil {
-		log.Fatalf("Gagal terhubung ke Redis tes: %v", err)
-	}
-	log.Println("Database dan Redis untuk tes berhasil terhubung.")
+	log.Println("Database untuk tes berhasil terhubung.")
 
 	clearDatabase(testDbPool)
 
-	jwtSecret := os.Getenv("JWT_SECRET_KEY")
-	refreshTokenSecret := os.Getenv("REFRESH_TOKEN_SECRET")
+	accessTokenKey := signing.NewHMACKey([]byte(os.Getenv("JWT_SECRET_KEY")))
+	refreshTokenKey := signing.NewHMACKey([]byte(os.Getenv("REFRESH_TOKEN_SECRET")))
+
+	kv := cache.NewLRU(0)
+	tokenRevocations := revocation.NewMemoryStore(revocation.DefaultRetention)
 
 	userRepo := repositories.NewPgxUserRepo(testDbPool)
+	sessionRepo := repositories.NewMemorySessionRepo()
+	loginAttemptRepo := repositories.NewLoginAttemptRepo(kv)
+	twoFactorRepo := repositories.NewPgxTwoFactorRepo(testDbPool)
+	passwordResetRepo := repositories.NewPgxPasswordResetRepo(testDbPool)
+	emailVerificationRepo := repositories.NewPgxEmailVerificationRepo(testDbPool)
 	articleRepo := repositories.NewPgxArticleRepo(testDbPool)
+	tagRepo := repositories.NewPgxTagRepo(testDbPool)
+	commentRepo := repositories.NewPgxCommentRepo(testDbPool)
+	apiKeyRepo := repositories.NewPgxAPIKeyRepo(testDbPool)
+
+	mail := mailer.NewLogMailer(io.Discard)
 
-	authService := services.NewAuthService(userRepo, jwtSecret, refreshTokenSecret, redisClient)
-	userService := services.NewUserService(userRepo)
-	articleService := services.NewArticleService(articleRepo, redisClient)
+	twoFactorService := services.NewTwoFactorService(twoFactorRepo, userRepo, loginAttemptRepo)
+	passwordResetService := services.NewPasswordResetService(userRepo, passwordResetRepo, sessionRepo, apiKeyRepo, mail, tokenRevocations)
+	authService := services.NewAuthService(userRepo, sessionRepo, apiKeyRepo, twoFactorRepo, loginAttemptRepo, accessTokenKey, refreshTokenKey, tokenRevocations)
+	userService := services.NewUserService(userRepo, emailVerificationRepo, sessionRepo, apiKeyRepo, mail, tokenRevocations)
+	articleService := services.NewArticleService(articleRepo, cache.NewLRU(1000), kv, userRepo, false, 0)
+	tagService := services.NewTagService(tagRepo)
+	commentService := services.NewCommentService(commentRepo, articleRepo)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo)
 
 	authHandler := handlers.NewAuthHandler(authService)
 	userHandler := handlers.NewUserHandler(userService)
 	articleHandler := handlers.NewArticleHandler(articleService)
+	tagHandler := handlers.NewTagHandler(tagService)
+	commentHandler := handlers.NewCommentHandler(commentService)
+	jwksHandler := handlers.NewJWKSHandler(accessTokenKey)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+	passwordResetHandler := handlers.NewPasswordResetHandler(passwordResetService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	oidcHandler := handlers.NewOIDCHandler(services.NewOIDCService(map[string]*oidc.Provider{}, repositories.NewOIDCStateRepo(kv), repositories.NewPgxExternalIdentityRepo(testDbPool), userRepo, authService))
 
 	routerDeps := router.Deps{
-		AuthHandler:    authHandler,
-		UserHandler:    userHandler,
-		ArticleHandler: articleHandler,
-		JWTSecret:      jwtSecret,
+		AuthHandler:      authHandler,
+		UserHandler:      userHandler,
+		ArticleHandler:   articleHandler,
+		TagHandler:       tagHandler,
+		CommentHandler:   commentHandler,
+		JWKSHandler:      jwksHandler,
+		TwoFactorHandler: twoFactorHandler,
+		PasswordHandler:  passwordResetHandler,
+		OIDCHandler:      oidcHandler,
+		APIKeyHandler:    apiKeyHandler,
+		TokenVerifier:    accessTokenKey,
+		Revocations:      tokenRevocations,
+		APIKeys:          apiKeyService,
 	}
 	testRouter = router.SetupRouter(routerDeps)
 
@@ -76,7 +107,7 @@ func TestMain(m *testing.M) {
 }
 
 func clearDatabase(pool *pgxpool.Pool) {
-	_, err := pool.Exec(context.Background(), "TRUNCATE TABLE articles, users RESTART IDENTITY CASCADE")
+	_, err := pool.Exec(context.Background(), "TRUNCATE TABLE articles, tags, users RESTART IDENTITY CASCADE")
 	if err != nil {
 		log.Fatalf("Gagal membersihkan database: %v", err)
 	}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/internal/services"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/middleware"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
+	"github.com/gorilla/mux"
+)
+
+type APIKeyHandler struct {
+	apiKeyService services.APIKeyService
+}
+
+func NewAPIKeyHandler(s services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: s}
+}
+
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	created, err := h.apiKeyService.CreateAPIKey(r.Context(), claims.UserID, req, claims.Scopes())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAPIKeyName), errors.Is(err, services.ErrInvalidScope), errors.Is(err, services.ErrInvalidAPIKeyExpiry):
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrScopeNotGranted):
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+		default:
+			utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, "API key created, store the key now as it will not be shown again", created)
+}
+
+func (h *APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	keys, err := h.apiKeyService.GetAPIKeys(r.Context(), claims.UserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "API keys retrieved successfully", keys)
+}
+
+func (h *APIKeyHandler) DeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	if err := h.apiKeyService.DeleteAPIKey(r.Context(), claims.UserID, mux.Vars(r)["id"]); err != nil {
+		if errors.Is(err, repositories.ErrAPIKeyNotFound) {
+			utils.WriteError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "API key deleted successfully", nil)
+}
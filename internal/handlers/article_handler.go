@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
 	"github.com/dhifanrazaqa/kumparan-article/internal/services"
 	"github.com/dhifanrazaqa/kumparan-article/pkg/middleware"
 	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
@@ -35,7 +38,7 @@ func (h *ArticleHandler) CreateArticle(w http.ResponseWriter, r *http.Request) {
 
 	article, err := h.articleService.CreateArticle(r.Context(), req, claims.UserID)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		writeArticleError(w, err)
 		return
 	}
 	utils.WriteJSON(w, http.StatusCreated, "Article created successfully", article)
@@ -53,19 +56,34 @@ func (h *ArticleHandler) GetArticles(w http.ResponseWriter, r *http.Request) {
 	}
 	offset := (page - 1) * limit
 
+	tagMode := queryParams.Get("tagMode")
+	if tagMode == "" {
+		tagMode = models.TagModeAny
+	}
+	if tagMode != models.TagModeAny && tagMode != models.TagModeAll {
+		utils.WriteError(w, http.StatusBadRequest, "tagMode must be 'any' or 'all'")
+		return
+	}
+
 	params := models.ListArticlesParams{
-		Query:  queryParams.Get("query"),
-		Author: queryParams.Get("author"),
-		Limit:  limit,
-		Offset: offset,
+		Query:   queryParams.Get("query"),
+		Author:  queryParams.Get("author"),
+		Status:  queryParams.Get("status"),
+		Tags:    queryParams["tag"],
+		TagMode: tagMode,
+		Limit:   limit,
+		Offset:  offset,
+	}
+	if claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims); ok {
+		params.ViewerID = claims.UserID
 	}
 
 	paginatedResult, err := h.articleService.GetArticles(r.Context(), params)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		writeArticleError(w, err)
 		return
 	}
-	
+
 	utils.WriteJSON(w, http.StatusOK, "Articles retrieved successfully", paginatedResult)
 }
 
@@ -73,7 +91,12 @@ func (h *ArticleHandler) GetArticleByID(w http.ResponseWriter, r *http.Request)
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	article, err := h.articleService.GetArticleByID(r.Context(), id)
+	var viewer models.Actor
+	if claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims); ok {
+		viewer = claims.Actor()
+	}
+
+	article, err := h.articleService.GetArticleByID(r.Context(), id, viewer)
 	if err != nil {
 		utils.WriteError(w, http.StatusNotFound, err.Error())
 		return
@@ -81,6 +104,28 @@ func (h *ArticleHandler) GetArticleByID(w http.ResponseWriter, r *http.Request)
 	utils.WriteJSON(w, http.StatusOK, "Article retrived successfully", article)
 }
 
+func (h *ArticleHandler) GetArticleBySlug(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	slug := vars["slug"]
+
+	var viewer models.Actor
+	if claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims); ok {
+		viewer = claims.Actor()
+	}
+
+	article, err := h.articleService.GetArticleBySlug(r.Context(), slug, viewer)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if article.Slug != slug {
+		http.Redirect(w, r, "/articles/by-slug/"+article.Slug, http.StatusMovedPermanently)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Article retrived successfully", article)
+}
+
 func (h *ArticleHandler) UpdateArticle(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
@@ -97,13 +142,9 @@ func (h *ArticleHandler) UpdateArticle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	article, err := h.articleService.UpdateArticle(r.Context(), id, req, claims.UserID)
+	article, err := h.articleService.UpdateArticle(r.Context(), id, req, claims.Actor())
 	if err != nil {
-		if err.Error() == "article not found" {
-			utils.WriteError(w, http.StatusNotFound, err.Error())
-		} else {
-			utils.WriteError(w, http.StatusForbidden, err.Error())
-		}
+		writeArticleError(w, err)
 		return
 	}
 	utils.WriteJSON(w, http.StatusOK, "Article updated successfully", article)
@@ -119,7 +160,7 @@ func (h *ArticleHandler) DeleteArticle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.articleService.DeleteArticle(r.Context(), id, claims.UserID)
+	err := h.articleService.DeleteArticle(r.Context(), id, claims.Actor())
 	if err != nil {
 		if err.Error() == "article not found" {
 			utils.WriteError(w, http.StatusNotFound, err.Error())
@@ -130,3 +171,130 @@ func (h *ArticleHandler) DeleteArticle(w http.ResponseWriter, r *http.Request) {
 	}
 	utils.WriteJSON(w, http.StatusOK, "Article deleted successfully", nil)
 }
+
+func (h *ArticleHandler) PublishArticle(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.articleService.PublishArticle, "Article published successfully")
+}
+
+func (h *ArticleHandler) UnpublishArticle(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.articleService.UnpublishArticle, "Article unpublished successfully")
+}
+
+func (h *ArticleHandler) ArchiveArticle(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.articleService.ArchiveArticle, "Article archived successfully")
+}
+
+func (h *ArticleHandler) changeStatus(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, id string, actor models.Actor) (*models.Article, error), message string) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	article, err := change(r.Context(), id, claims.Actor())
+	if err != nil {
+		writeArticleError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, message, article)
+}
+
+func (h *ArticleHandler) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	revisions, err := h.articleService.GetRevisions(r.Context(), id, claims.Actor())
+	if err != nil {
+		writeArticleError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Revisions retrieved successfully", revisions)
+}
+
+func (h *ArticleHandler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	rev, _ := strconv.Atoi(vars["rev"])
+
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	revision, err := h.articleService.GetRevision(r.Context(), id, rev, claims.Actor())
+	if err != nil {
+		writeArticleError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Revision retrieved successfully", revision)
+}
+
+func (h *ArticleHandler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	queryParams := r.URL.Query()
+	from, errFrom := strconv.Atoi(queryParams.Get("from"))
+	to, errTo := strconv.Atoi(queryParams.Get("to"))
+	if errFrom != nil || errTo != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Query params 'from' and 'to' must be revision numbers")
+		return
+	}
+
+	diff, err := h.articleService.DiffRevisions(r.Context(), id, from, to, claims.Actor())
+	if err != nil {
+		writeArticleError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Revision diff retrieved successfully", diff)
+}
+
+func (h *ArticleHandler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	rev, _ := strconv.Atoi(vars["rev"])
+
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	article, err := h.articleService.RestoreRevision(r.Context(), id, rev, claims.Actor())
+	if err != nil {
+		writeArticleError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Revision restored successfully", article)
+}
+
+func writeArticleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrArticleNotFound), errors.Is(err, repositories.ErrRevisionNotFound):
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrForbidden), errors.Is(err, services.ErrEmailNotVerified):
+		utils.WriteError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrInvalidArticleStatus), errors.Is(err, services.ErrInvalidTag):
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrInvalidStatusTransition), errors.Is(err, repositories.ErrSlugTaken):
+		utils.WriteError(w, http.StatusConflict, err.Error())
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -2,11 +2,17 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
 	"github.com/dhifanrazaqa/kumparan-article/internal/services"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/middleware"
 	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
+	"github.com/gorilla/mux"
 )
 
 type AuthHandler struct {
@@ -17,6 +23,18 @@ func NewAuthHandler(s services.AuthService) *AuthHandler {
 	return &AuthHandler{authService: s}
 }
 
+// writeTooManyAttempts answers a lockout with 429 and Retry-After, and reports
+// whether err was one.
+func writeTooManyAttempts(w http.ResponseWriter, err error) bool {
+	var throttled *services.TooManyAttemptsError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+	utils.WriteError(w, http.StatusTooManyRequests, err.Error())
+	return true
+}
+
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req models.LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -24,8 +42,40 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	authResponse, err := h.authService.Login(r.Context(), req)
+	client := models.ClientInfo{UserAgent: r.UserAgent(), IP: utils.ClientIP(r)}
+	authResponse, err := h.authService.Login(r.Context(), req, client)
 	if err != nil {
+		if writeTooManyAttempts(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidScope) {
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	if authResponse.TwoFactorRequired {
+		utils.WriteJSON(w, http.StatusOK, "Two-factor authentication required", authResponse)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Successfully logged in", authResponse)
+}
+
+func (h *AuthHandler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req models.TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	client := models.ClientInfo{UserAgent: r.UserAgent(), IP: utils.ClientIP(r)}
+	authResponse, err := h.authService.LoginTwoFactor(r.Context(), req, client)
+	if err != nil {
+		if writeTooManyAttempts(w, err) {
+			return
+		}
 		utils.WriteError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
@@ -47,7 +97,90 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	}
 
 	utils.WriteJSON(w, http.StatusOK, "Successfully refreshed access token", map[string]string{
-		"accessToken": newAccessToken.AccessToken,
+		"accessToken":  newAccessToken.AccessToken,
 		"refreshToken": newAccessToken.RefreshToken,
 	})
 }
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.authService.Logout(r.Context(), req.RefreshToken); err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Successfully logged out", nil)
+}
+
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	revokeAPIKeys := r.URL.Query().Get("apiKeys") == "true"
+	if err := h.authService.LogoutAll(r.Context(), claims.UserID, revokeAPIKeys); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Successfully logged out from all sessions", nil)
+}
+
+func (h *AuthHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	sessions, err := h.authService.GetSessions(r.Context(), claims.UserID, claims.SessionID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Sessions retrieved successfully", sessions)
+}
+
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	if err := h.authService.RevokeSession(r.Context(), claims.UserID, vars["id"]); err != nil {
+		if errors.Is(err, repositories.ErrSessionNotFound) {
+			utils.WriteError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Session revoked successfully", nil)
+}
+
+func (h *AuthHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.authService.UnlockUser(r.Context(), vars["id"]); err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			utils.WriteError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "User login lockout cleared successfully", nil)
+}
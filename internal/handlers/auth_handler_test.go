@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/internal/services"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/cache"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+type unknownUserRepo struct {
+	repositories.UserRepository
+}
+
+func (unknownUserRepo) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	return nil, repositories.ErrUserNotFound
+}
+
+func TestAuthHandler_Login_IPThrottle(t *testing.T) {
+	authService := services.NewAuthService(unknownUserRepo{}, nil, nil, nil, repositories.NewLoginAttemptRepo(cache.NewLRU(0)), nil, nil, nil)
+	handler := middleware.RealIP(nil)(http.HandlerFunc(NewAuthHandler(authService).Login))
+
+	login := func(i int, remoteAddr, forwardedFor string) int {
+		body := fmt.Sprintf(`{"username": "user-%d", "password": "salah"}`, i)
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	// Every attempt uses a new username, so only the per-IP rule applies.
+	for i := 0; i < 21; i++ {
+		assert.Equal(t, http.StatusUnauthorized, login(i, "203.0.113.5:4000", fmt.Sprintf("198.51.100.%d", i)))
+	}
+
+	t.Run("header palsu yang berganti tidak melewati batas per IP", func(t *testing.T) {
+		assert.Equal(t, http.StatusTooManyRequests, login(21, "203.0.113.5:4000", "198.51.100.200"))
+	})
+
+	t.Run("alamat korban di header palsu tidak mengunci korban", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, login(22, "198.51.100.1:5000", ""))
+	})
+}
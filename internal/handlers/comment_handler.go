@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/internal/services"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/middleware"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
+	"github.com/gorilla/mux"
+)
+
+type CommentHandler struct {
+	commentService services.CommentService
+}
+
+func NewCommentHandler(s services.CommentService) *CommentHandler {
+	return &CommentHandler{commentService: s}
+}
+
+func (h *CommentHandler) GetComments(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	queryParams := r.URL.Query()
+	limit, _ := strconv.Atoi(queryParams.Get("limit"))
+	if limit <= 0 {
+		limit = 20
+	}
+	page, _ := strconv.Atoi(queryParams.Get("page"))
+	if page <= 0 {
+		page = 1
+	}
+
+	params := models.ListCommentsParams{
+		ArticleID: vars["id"],
+		Limit:     limit,
+		Offset:    (page - 1) * limit,
+	}
+	if claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims); ok {
+		params.Viewer = claims.Actor()
+	}
+
+	paginatedResult, err := h.commentService.GetComments(r.Context(), params)
+	if err != nil {
+		writeCommentError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Comments retrieved successfully", paginatedResult)
+}
+
+func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	var req models.CreateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.Body) == "" {
+		utils.WriteError(w, http.StatusBadRequest, "Comment body cannot be empty")
+		return
+	}
+
+	comment, err := h.commentService.CreateComment(r.Context(), vars["id"], req, claims.Actor())
+	if err != nil {
+		writeCommentError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusCreated, "Comment created successfully", comment)
+}
+
+func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	var req models.UpdateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.Body) == "" {
+		utils.WriteError(w, http.StatusBadRequest, "Comment body cannot be empty")
+		return
+	}
+
+	comment, err := h.commentService.UpdateComment(r.Context(), vars["id"], vars["commentId"], req, claims.Actor())
+	if err != nil {
+		writeCommentError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Comment updated successfully", comment)
+}
+
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	if err := h.commentService.DeleteComment(r.Context(), vars["id"], vars["commentId"], claims.Actor()); err != nil {
+		writeCommentError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Comment deleted successfully", nil)
+}
+
+func writeCommentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrArticleNotFound), errors.Is(err, repositories.ErrCommentNotFound):
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrForbidden):
+		utils.WriteError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrInvalidParentComment):
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+	}
+}
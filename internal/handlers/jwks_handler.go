@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dhifanrazaqa/kumparan-article/pkg/signing"
+)
+
+type JWKSHandler struct {
+	keys signing.KeySet
+}
+
+func NewJWKSHandler(keys signing.KeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// GetJWKS serves the public signing keys as a plain JWK Set (RFC 7517), not
+// wrapped in the usual response envelope, so standard JWT libraries can
+// consume it directly.
+func (h *JWKSHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.keys.JWKS())
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/services"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/oidc"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
+	"github.com/gorilla/mux"
+)
+
+// oidcStateCookie binds a login to the browser that started it, so an
+// attacker cannot complete their own login in a victim's browser.
+const oidcStateCookie = "oidc_state"
+
+type OIDCHandler struct {
+	oidcService services.OIDCService
+}
+
+func NewOIDCHandler(s services.OIDCService) *OIDCHandler {
+	return &OIDCHandler{oidcService: s}
+}
+
+func (h *OIDCHandler) Start(w http.ResponseWriter, r *http.Request) {
+	provider := mux.Vars(r)["provider"]
+
+	authURL, state, err := h.oidcService.StartLogin(r.Context(), provider)
+	if err != nil {
+		writeOIDCError(w, err)
+		return
+	}
+
+	setOIDCStateCookie(w, r, state, 600)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	provider := mux.Vars(r)["provider"]
+	query := r.URL.Query()
+
+	if errCode := query.Get("error"); errCode != "" {
+		utils.WriteError(w, http.StatusUnauthorized, "Identity provider returned an error: "+errCode)
+		return
+	}
+
+	state, code := query.Get("state"), query.Get("code")
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || state == "" || code == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		utils.WriteError(w, http.StatusBadRequest, services.ErrInvalidOIDCState.Error())
+		return
+	}
+	setOIDCStateCookie(w, r, "", -1)
+
+	client := models.ClientInfo{UserAgent: r.UserAgent(), IP: utils.ClientIP(r)}
+	authResponse, err := h.oidcService.CompleteLogin(r.Context(), provider, state, code, client)
+	if err != nil {
+		writeOIDCError(w, err)
+		return
+	}
+
+	if authResponse.TwoFactorRequired {
+		utils.WriteJSON(w, http.StatusOK, "Two-factor authentication required", authResponse)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Successfully logged in", authResponse)
+}
+
+func setOIDCStateCookie(w http.ResponseWriter, r *http.Request, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     "/auth/oidc",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func writeOIDCError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrUnknownProvider):
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrInvalidOIDCState):
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrOIDCLoginFailed):
+		utils.WriteError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, services.ErrOIDCEmailNotVerified):
+		utils.WriteError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, oidc.ErrDiscovery):
+		utils.WriteError(w, http.StatusBadGateway, "Identity provider is unavailable")
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/internal/services"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
+)
+
+type PasswordResetHandler struct {
+	passwordResetService services.PasswordResetService
+}
+
+func NewPasswordResetHandler(s services.PasswordResetService) *PasswordResetHandler {
+	return &PasswordResetHandler{passwordResetService: s}
+}
+
+func (h *PasswordResetHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.passwordResetService.ForgotPassword(r.Context(), req.Email); err != nil {
+		if errors.Is(err, services.ErrInvalidEmail) {
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusAccepted, "If the email address is registered, a password reset token has been sent to it", nil)
+}
+
+func (h *PasswordResetHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.passwordResetService.ResetPassword(r.Context(), req); err != nil {
+		switch {
+		case errors.Is(err, services.ErrPasswordTooShort),
+			errors.Is(err, services.ErrPasswordTooLong),
+			errors.Is(err, repositories.ErrPasswordResetTokenInvalid):
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Password has been reset, please log in again", nil)
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/services"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
+)
+
+type TagHandler struct {
+	tagService services.TagService
+}
+
+func NewTagHandler(s services.TagService) *TagHandler {
+	return &TagHandler{tagService: s}
+}
+
+func (h *TagHandler) GetTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.tagService.GetTags(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Tags retrieved successfully", tags)
+}
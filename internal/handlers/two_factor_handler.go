@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/internal/services"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/middleware"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService services.TwoFactorService
+}
+
+func NewTwoFactorHandler(s services.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorService: s}
+}
+
+func writeTwoFactorError(w http.ResponseWriter, err error) {
+	if writeTooManyAttempts(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+		utils.WriteError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrTwoFactorNotEnabled),
+		errors.Is(err, repositories.ErrTwoFactorNotFound),
+		errors.Is(err, services.ErrInvalidTwoFactorCode):
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func (h *TwoFactorHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	enrollment, err := h.twoFactorService.Enroll(r.Context(), claims.UserID)
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Scan the provisioning URI and confirm with a code to enable two-factor authentication", enrollment)
+}
+
+func (h *TwoFactorHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	codes, err := h.twoFactorService.Enable(r.Context(), claims.UserID, req.Code)
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Two-factor authentication enabled, store the recovery codes safely", models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.twoFactorService.Disable(r.Context(), claims.UserID, req.Code); err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Two-factor authentication disabled", nil)
+}
+
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(r.Context(), claims.UserID, req.Code)
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Recovery codes regenerated, the previous codes no longer work", models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
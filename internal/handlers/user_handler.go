@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
 	"github.com/dhifanrazaqa/kumparan-article/internal/services"
 	"github.com/dhifanrazaqa/kumparan-article/pkg/middleware"
 	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
@@ -35,7 +37,8 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.userService.CreateUser(r.Context(), req)
 	if err != nil {
-		if err.Error() == "user already exists" {
+		if err.Error() == "user already exists" || errors.Is(err, services.ErrEmailAlreadyExists) || errors.Is(err, services.ErrInvalidEmail) ||
+			errors.Is(err, services.ErrPasswordTooShort) || errors.Is(err, services.ErrPasswordTooLong) {
 			utils.WriteError(w, http.StatusBadRequest, err.Error())
 			return
 		}
@@ -82,8 +85,12 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userService.UpdateUser(r.Context(), id, req, claims.UserID)
+	user, err := h.userService.UpdateUser(r.Context(), id, req, claims.Actor())
 	if err != nil {
+		if errors.Is(err, services.ErrEmailAlreadyExists) || errors.Is(err, services.ErrInvalidEmail) || errors.Is(err, services.ErrPasswordNotUpdatable) {
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		utils.WriteError(w, http.StatusForbidden, err.Error())
 		return
 	}
@@ -100,7 +107,7 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.userService.DeleteUser(r.Context(), id, claims.UserID)
+	err := h.userService.DeleteUser(r.Context(), id, claims.Actor())
 	if err != nil {
 		utils.WriteError(w, http.StatusForbidden, err.Error())
 		return
@@ -108,3 +115,140 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 
 	utils.WriteJSON(w, http.StatusOK, "User deleted successfully", nil)
 }
+
+// writeMeError maps the errors of the /users/me endpoints to a status code.
+func writeMeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrEmailAlreadyExists), errors.Is(err, services.ErrInvalidEmail),
+		errors.Is(err, services.ErrPasswordTooShort), errors.Is(err, services.ErrPasswordTooLong):
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrIncorrectPassword), errors.Is(err, services.ErrForbidden):
+		utils.WriteError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, repositories.ErrUserNotFound):
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	user, err := h.userService.GetProfile(r.Context(), claims.UserID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			utils.WriteError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "User retrieved successfully", user)
+}
+
+func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	var req models.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Request body is not valid")
+		return
+	}
+
+	update := models.UpdateUserRequest{Username: req.Username, Name: req.Name, Email: req.Email, CurrentPassword: req.CurrentPassword}
+	user, err := h.userService.UpdateUser(r.Context(), claims.UserID, update, claims.Actor())
+	if err != nil {
+		writeMeError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "User updated successfully", user)
+}
+
+func (h *UserHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	if err := h.userService.DeleteUser(r.Context(), claims.UserID, claims.Actor()); err != nil {
+		writeMeError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "User deleted successfully", nil)
+}
+
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.ClaimsContextKey).(*models.Claims)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get user data from token")
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Request body is not valid")
+		return
+	}
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		utils.WriteError(w, http.StatusBadRequest, "Current and new password cannot be empty")
+		return
+	}
+
+	if err := h.userService.ChangePassword(r.Context(), claims.UserID, req); err != nil {
+		writeMeError(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Password changed successfully, please log in again", nil)
+}
+
+func (h *UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req models.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		utils.WriteError(w, http.StatusBadRequest, "Token cannot be empty")
+		return
+	}
+
+	if err := h.userService.VerifyEmail(r.Context(), req.Token); err != nil {
+		if errors.Is(err, repositories.ErrEmailVerificationTokenInvalid) {
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Email address verified successfully", nil)
+}
+
+func (h *UserHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req models.UpdateUserRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Request body is not valid")
+		return
+	}
+
+	user, err := h.userService.UpdateUserRole(r.Context(), id, req.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidRole):
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, repositories.ErrUserNotFound):
+			utils.WriteError(w, http.StatusNotFound, err.Error())
+		default:
+			utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "User role updated successfully", user)
+}
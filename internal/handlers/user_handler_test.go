@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/internal/services"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/middleware"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserHandler_UpdateUser_RejectsPassword(t *testing.T) {
+	handler := NewUserHandler(services.NewUserService(nil, nil, nil, nil, nil, nil))
+
+	req := httptest.NewRequest(http.MethodPut, "/users/user-1", strings.NewReader(`{"name": "Budi", "password": "password-baru"}`))
+	req = mux.SetURLVars(req, map[string]string{"id": "user-1"})
+	req = req.WithContext(context.WithValue(req.Context(), middleware.ClaimsContextKey, &models.Claims{UserID: "user-1", Role: models.RoleUser}))
+	rr := httptest.NewRecorder()
+	handler.UpdateUser(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "PUT /users/me/password")
+}
+
+type deletedUserRepo struct {
+	repositories.UserRepository
+}
+
+func (deletedUserRepo) Delete(ctx context.Context, id string) error {
+	return repositories.ErrUserNotFound
+}
+
+func TestUserHandler_DeleteMe_UserNotFound(t *testing.T) {
+	handler := NewUserHandler(services.NewUserService(deletedUserRepo{}, nil, nil, nil, nil, nil))
+
+	req := httptest.NewRequest(http.MethodDelete, "/users/me", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.ClaimsContextKey, &models.Claims{UserID: "user-1", Role: models.RoleUser}))
+	rr := httptest.NewRecorder()
+	handler.DeleteMe(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+const (
+	ScopeArticlesWrite = "articles:write"
+	ScopeCommentsWrite = "comments:write"
+	ScopeUsersWrite    = "users:write"
+)
+
+// AllScopes is granted when a login or API key omits the scopes field.
+var AllScopes = []string{ScopeArticlesWrite, ScopeCommentsWrite, ScopeUsersWrite}
+
+// ScopeClaimVersion marks claims whose scope claim is authoritative, so an
+// empty one means no scopes rather than a token from before scopes existed.
+const ScopeClaimVersion = 1
+
+type APIKey struct {
+	ID           string     `json:"id"`
+	UserID       string     `json:"-"`
+	Name         string     `json:"name"`
+	Prefix       string     `json:"prefix"`
+	HashedSecret string     `json:"-"`
+	Scopes       []string   `json:"scopes"`
+	ExpiresAt    *time.Time `json:"expiresAt"`
+	LastUsedAt   *time.Time `json:"lastUsedAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// CreatedAPIKey is returned once, on creation; the key cannot be retrieved
+// again.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
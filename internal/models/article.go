@@ -2,31 +2,53 @@ package models
 
 import "time"
 
+const (
+	ArticleStatusDraft     = "draft"
+	ArticleStatusPublished = "published"
+	ArticleStatusArchived  = "archived"
+)
+
+const (
+	TagModeAny = "any"
+	TagModeAll = "all"
+)
+
 type Article struct {
-	ID        string        `json:"id"`
-	Title     string        `json:"title"`
-	Body      string        `json:"body"`
-	AuthorID  string        `json:"authorId"`
-	CreatedAt time.Time     `json:"createdAt"`
-	UpdatedAt time.Time     `json:"updatedAt"`
-	Author    *UserResponse `json:"author,omitempty"`
+	ID          string        `json:"id"`
+	Title       string        `json:"title"`
+	Slug        string        `json:"slug"`
+	Body        string        `json:"body"`
+	AuthorID    string        `json:"authorId"`
+	Status      string        `json:"status"`
+	PublishedAt *time.Time    `json:"publishedAt,omitempty"`
+	Tags        []string      `json:"tags"`
+	CreatedAt   time.Time     `json:"createdAt"`
+	UpdatedAt   time.Time     `json:"updatedAt"`
+	Author      *UserResponse `json:"author,omitempty"`
 }
 
 type CreateArticleRequest struct {
-	Title string `json:"title"`
-	Body  string `json:"body"`
+	Title  string   `json:"title"`
+	Body   string   `json:"body"`
+	Status string   `json:"status,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
 }
 
 type UpdateArticleRequest struct {
-	Title string `json:"title,omitempty"`
-	Body  string `json:"body,omitempty"`
+	Title string   `json:"title,omitempty"`
+	Body  string   `json:"body,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
 }
 
 type ListArticlesParams struct {
-	Query  string
-	Author string
-	Limit  int
-	Offset int
+	Query    string
+	Author   string
+	Status   string
+	Tags     []string
+	TagMode  string
+	ViewerID string
+	Limit    int
+	Offset   int
 }
 
 type PaginatedArticles struct {
@@ -35,4 +57,26 @@ type PaginatedArticles struct {
 	Page       int       `json:"page"`
 	Limit      int       `json:"limit"`
 	TotalPages int       `json:"totalPages"`
-}
\ No newline at end of file
+}
+
+type ArticleRevision struct {
+	ID        string    `json:"id"`
+	ArticleID string    `json:"articleId"`
+	Revision  int       `json:"revision"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	AuthorID  string    `json:"authorId,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+type RevisionDiff struct {
+	From  int        `json:"from"`
+	To    int        `json:"to"`
+	Title []DiffLine `json:"title"`
+	Body  []DiffLine `json:"body"`
+}
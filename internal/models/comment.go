@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+type Comment struct {
+	ID        string        `json:"id"`
+	ArticleID string        `json:"articleId"`
+	ParentID  *string       `json:"parentId,omitempty"`
+	AuthorID  string        `json:"authorId"`
+	Body      string        `json:"body"`
+	CreatedAt time.Time     `json:"createdAt"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+	Author    *UserResponse `json:"author,omitempty"`
+	Replies   []Comment     `json:"replies,omitempty"`
+}
+
+type CreateCommentRequest struct {
+	Body     string  `json:"body"`
+	ParentID *string `json:"parentId,omitempty"`
+}
+
+type UpdateCommentRequest struct {
+	Body string `json:"body"`
+}
+
+type ListCommentsParams struct {
+	ArticleID string
+	Viewer    Actor
+	Limit     int
+	Offset    int
+}
+
+type PaginatedComments struct {
+	Data       []Comment `json:"data"`
+	Total      int64     `json:"total"`
+	Page       int       `json:"page"`
+	Limit      int       `json:"limit"`
+	TotalPages int       `json:"totalPages"`
+}
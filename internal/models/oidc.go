@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// ExternalIdentity links an account at an external identity provider to a
+// local user.
+type ExternalIdentity struct {
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OIDCLoginState is what the start of an OIDC login remembers for the
+// callback, keyed by the state parameter.
+type OIDCLoginState struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"codeVerifier"`
+}
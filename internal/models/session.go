@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type Session struct {
+	ID             string    `json:"id"`
+	UserID         string    `json:"-"`
+	RefreshTokenID string    `json:"-"`
+	UserAgent      string    `json:"userAgent"`
+	IP             string    `json:"ip"`
+	CreatedAt      time.Time `json:"createdAt"`
+	LastUsedAt     time.Time `json:"lastUsedAt"`
+	Scopes         []string  `json:"scopes,omitempty"`
+	Current        bool      `json:"current"`
+}
+
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
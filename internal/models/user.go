@@ -1,48 +1,94 @@
 package models
 
 import (
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+const (
+	RoleUser   = "user"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
 type User struct {
-	ID             string    `json:"id"`
-	Username       string    `json:"username"`
-	Name           string    `json:"name"`
-	HashedPassword string    `json:"-"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID              string     `json:"id"`
+	Username        string     `json:"username"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	Role            string     `json:"role"`
+	HashedPassword  string     `json:"-"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 type UserResponse struct {
-	ID        string    `json:"id"`
-	Username  string    `json:"username"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            string    `json:"id"`
+	Username      string    `json:"username"`
+	Name          string    `json:"name"`
+	Email         string    `json:"email,omitempty"`
+	EmailVerified *bool     `json:"email_verified,omitempty"`
+	Role          string    `json:"role,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 type CreateUserRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=50"`
 	Name     string `json:"name" validate:"required,min=3,max=50"`
-	Password string `json:"password" validate:"required,min=8,max=100"`
+	Email    string `json:"email" validate:"omitempty,email"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
 }
 
+// UpdateUserRequest is the body of PUT /users/{id}. Like PATCH /users/me it
+// cannot change the password, which needs PUT /users/me/password.
 type UpdateUserRequest struct {
 	Username string `json:"username" validate:"omitempty,min=3,max=50"`
 	Name     string `json:"name" validate:"omitempty,min=3,max=50"`
-	Password string `json:"password" validate:"omitempty,min=8,max=100"`
+	Email    string `json:"email" validate:"omitempty,email"`
+	// CurrentPassword is required when users change their own email address.
+	CurrentPassword string `json:"current_password"`
+	// Password is only read so that requests still sending it are refused
+	// instead of silently keeping the old password.
+	Password *string `json:"password"`
+}
+
+// UpdateProfileRequest is the body of PATCH /users/me. Passwords are changed
+// through PUT /users/me/password, which asks for the current one.
+type UpdateProfileRequest struct {
+	Username string `json:"username" validate:"omitempty,min=3,max=50"`
+	Name     string `json:"name" validate:"omitempty,min=3,max=50"`
+	Email    string `json:"email" validate:"omitempty,email"`
+	// CurrentPassword is required when Email changes.
+	CurrentPassword string `json:"current_password"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=100"`
+}
+
+type UpdateUserRoleRequest struct {
+	Role string `json:"role"`
 }
 
 type LoginRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	Scopes   []string `json:"scopes,omitempty"`
 }
 
+// AuthResponse carries either a token pair or, for accounts with two-factor
+// authentication, the challenge token to complete at /auth/login/2fa.
 type AuthResponse struct {
-	AccessToken  string `json:"accessToken"`
-	RefreshToken string `json:"refreshToken"`
+	AccessToken       string `json:"accessToken,omitempty"`
+	RefreshToken      string `json:"refreshToken,omitempty"`
+	TwoFactorRequired bool   `json:"twoFactorRequired,omitempty"`
+	ChallengeToken    string `json:"challengeToken,omitempty"`
 }
 
 type RefreshTokenRequest struct {
@@ -50,7 +96,50 @@ type RefreshTokenRequest struct {
 }
 
 type Claims struct {
-	UserID   string `json:"userId"`
-	Username string `json:"username"`
+	UserID    string `json:"userId"`
+	Username  string `json:"username"`
+	Role      string `json:"role,omitempty"`
+	SessionID string `json:"sid,omitempty"`
+	// Scope is a space separated list of granted scopes.
+	Scope string `json:"scope,omitempty"`
+	// ScopeVersion is ScopeClaimVersion on every token that carries scopes.
+	ScopeVersion int `json:"scv,omitempty"`
 	jwt.RegisteredClaims
 }
+
+// Actor identifies who is performing an action, as consulted by the policy package.
+type Actor struct {
+	UserID string
+	Role   string
+}
+
+func (c *Claims) Actor() Actor {
+	return Actor{UserID: c.UserID, Role: c.Role}
+}
+
+// Scopes returns the scopes the token grants. Only tokens issued before
+// scopes existed, which carry neither a scope claim nor ScopeVersion, keep
+// full access until they expire; any other token without scopes is read-only.
+func (c *Claims) Scopes() []string {
+	if c.Scope == "" && c.ScopeVersion < ScopeClaimVersion {
+		return append([]string(nil), AllScopes...)
+	}
+	return strings.Fields(c.Scope)
+}
+
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes(), scope)
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
@@ -0,0 +1,55 @@
+package policy
+
+import "github.com/dhifanrazaqa/kumparan-article/internal/models"
+
+func isModerator(actor models.Actor) bool {
+	return actor.Role == models.RoleEditor || actor.Role == models.RoleAdmin
+}
+
+func IsAdmin(actor models.Actor) bool {
+	return actor.Role == models.RoleAdmin
+}
+
+func IsValidRole(role string) bool {
+	switch role {
+	case models.RoleUser, models.RoleEditor, models.RoleAdmin:
+		return true
+	}
+	return false
+}
+
+func IsValidScope(scope string) bool {
+	switch scope {
+	case models.ScopeArticlesWrite, models.ScopeCommentsWrite, models.ScopeUsersWrite:
+		return true
+	}
+	return false
+}
+
+func CanViewArticle(actor models.Actor, article *models.Article) bool {
+	if article.Status == models.ArticleStatusPublished {
+		return true
+	}
+	return actor.UserID != "" && (actor.UserID == article.AuthorID || isModerator(actor))
+}
+
+// CanEditArticle covers content edits, status changes and revision history.
+func CanEditArticle(actor models.Actor, article *models.Article) bool {
+	return actor.UserID == article.AuthorID || isModerator(actor)
+}
+
+func CanDeleteArticle(actor models.Actor, article *models.Article) bool {
+	return actor.UserID == article.AuthorID || isModerator(actor)
+}
+
+func CanEditComment(actor models.Actor, comment *models.Comment) bool {
+	return actor.UserID == comment.AuthorID
+}
+
+func CanDeleteComment(actor models.Actor, comment *models.Comment, article *models.Article) bool {
+	return actor.UserID == comment.AuthorID || actor.UserID == article.AuthorID || isModerator(actor)
+}
+
+func CanManageUser(actor models.Actor, userID string) bool {
+	return actor.UserID == userID || IsAdmin(actor)
+}
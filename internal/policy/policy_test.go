@@ -0,0 +1,68 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArticlePolicy(t *testing.T) {
+	draft := &models.Article{AuthorID: "author", Status: models.ArticleStatusDraft}
+
+	author := models.Actor{UserID: "author", Role: models.RoleUser}
+	stranger := models.Actor{UserID: "stranger", Role: models.RoleUser}
+	editor := models.Actor{UserID: "editor", Role: models.RoleEditor}
+	anonymous := models.Actor{}
+
+	assert.True(t, CanViewArticle(author, draft))
+	assert.True(t, CanViewArticle(editor, draft))
+	assert.False(t, CanViewArticle(stranger, draft))
+	assert.False(t, CanViewArticle(anonymous, draft))
+	assert.True(t, CanViewArticle(anonymous, &models.Article{Status: models.ArticleStatusPublished}))
+
+	assert.True(t, CanEditArticle(author, draft))
+	assert.True(t, CanEditArticle(editor, draft))
+	assert.False(t, CanEditArticle(stranger, draft))
+}
+
+func TestCanViewArticle(t *testing.T) {
+	author := models.Actor{UserID: "author", Role: models.RoleUser}
+	stranger := models.Actor{UserID: "stranger", Role: models.RoleUser}
+	editor := models.Actor{UserID: "editor", Role: models.RoleEditor}
+	admin := models.Actor{UserID: "admin", Role: models.RoleAdmin}
+	anonymous := models.Actor{}
+
+	tests := []struct {
+		name   string
+		actor  models.Actor
+		status string
+		want   bool
+	}{
+		{"publik melihat artikel terbit", anonymous, models.ArticleStatusPublished, true},
+		{"pengguna lain melihat artikel terbit", stranger, models.ArticleStatusPublished, true},
+		{"publik tidak melihat draft", anonymous, models.ArticleStatusDraft, false},
+		{"pengguna lain tidak melihat draft", stranger, models.ArticleStatusDraft, false},
+		{"penulis melihat draft sendiri", author, models.ArticleStatusDraft, true},
+		{"editor melihat draft", editor, models.ArticleStatusDraft, true},
+		{"admin melihat draft", admin, models.ArticleStatusDraft, true},
+		{"publik tidak melihat arsip", anonymous, models.ArticleStatusArchived, false},
+		{"pengguna lain tidak melihat arsip", stranger, models.ArticleStatusArchived, false},
+		{"penulis melihat arsip sendiri", author, models.ArticleStatusArchived, true},
+		{"editor melihat arsip", editor, models.ArticleStatusArchived, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article := &models.Article{AuthorID: "author", Status: tt.status}
+			assert.Equal(t, tt.want, CanViewArticle(tt.actor, article))
+		})
+	}
+}
+
+func TestUserPolicy(t *testing.T) {
+	assert.True(t, CanManageUser(models.Actor{UserID: "u1", Role: models.RoleUser}, "u1"))
+	assert.False(t, CanManageUser(models.Actor{UserID: "u1", Role: models.RoleUser}, "u2"))
+	assert.False(t, CanManageUser(models.Actor{UserID: "u1", Role: models.RoleEditor}, "u2"))
+	assert.True(t, CanManageUser(models.Actor{UserID: "u1", Role: models.RoleAdmin}, "u2"))
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+)
+
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	FindByUserID(ctx context.Context, userID string) ([]models.APIKey, error)
+	// FindByPrefix returns the key together with its owner.
+	FindByPrefix(ctx context.Context, prefix string) (*models.APIKey, *models.User, error)
+	TouchLastUsed(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string, userID string) error
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+type pgxAPIKeyRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewPgxAPIKeyRepo(pool *pgxpool.Pool) APIKeyRepository {
+	return &pgxAPIKeyRepo{pool: pool}
+}
+
+func (r *pgxAPIKeyRepo) Create(ctx context.Context, key *models.APIKey) error {
+	query := `INSERT INTO api_keys (user_id, name, prefix, hashed_secret, scopes, expires_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`
+	row := r.pool.QueryRow(ctx, query, key.UserID, key.Name, key.Prefix, key.HashedSecret, key.Scopes, key.ExpiresAt)
+	return row.Scan(&key.ID, &key.CreatedAt)
+}
+
+func (r *pgxAPIKeyRepo) FindByUserID(ctx context.Context, userID string) ([]models.APIKey, error) {
+	query := `SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var key models.APIKey
+		if err := rows.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Scopes, &key.ExpiresAt, &key.LastUsedAt, &key.CreatedAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *pgxAPIKeyRepo) FindByPrefix(ctx context.Context, prefix string) (*models.APIKey, *models.User, error) {
+	query := `
+		SELECT k.id, k.user_id, k.name, k.prefix, k.hashed_secret, k.scopes, k.expires_at, k.last_used_at, k.created_at, u.username, u.role
+		FROM api_keys k
+		JOIN users u ON u.id = k.user_id
+		WHERE k.prefix = $1`
+
+	var key models.APIKey
+	var user models.User
+	err := r.pool.QueryRow(ctx, query, prefix).Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.HashedSecret, &key.Scopes, &key.ExpiresAt, &key.LastUsedAt, &key.CreatedAt, &user.Username, &user.Role)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrAPIKeyNotFound
+		}
+		return nil, nil, err
+	}
+	user.ID = key.UserID
+	return &key, &user, nil
+}
+
+// TouchLastUsed records a use of the key at most once a minute, so busy keys
+// do not turn every request into a write.
+func (r *pgxAPIKeyRepo) TouchLastUsed(ctx context.Context, id string) error {
+	query := `UPDATE api_keys SET last_used_at = NOW() WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`
+	_, err := r.pool.Exec(ctx, query, id)
+	return err
+}
+
+func (r *pgxAPIKeyRepo) Delete(ctx context.Context, id string, userID string) error {
+	query := `DELETE FROM api_keys WHERE id = $1 AND user_id = $2`
+	cmdTag, err := r.pool.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *pgxAPIKeyRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM api_keys WHERE user_id = $1`, userID)
+	return err
+}
@@ -8,18 +8,30 @@ import (
 
 	"github.com/dhifanrazaqa/kumparan-article/internal/models"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-var ErrArticleNotFound = errors.New("article not found")
+var (
+	ErrArticleNotFound  = errors.New("article not found")
+	ErrRevisionNotFound = errors.New("revision not found")
+	// ErrSlugTaken is returned by Create and Update when another article
+	// claimed the slug between SlugTaken and the write.
+	ErrSlugTaken = errors.New("article slug already taken")
+)
 
 type ArticleRepository interface {
 	Create(ctx context.Context, article *models.Article) error
 	FindByID(ctx context.Context, id string) (*models.Article, error)
 	FindAll(ctx context.Context, params models.ListArticlesParams) ([]models.Article, error)
-	Update(ctx context.Context, article *models.Article) error
+	Update(ctx context.Context, article *models.Article, editorID string) error
 	Delete(ctx context.Context, id string) error
 	CountAll(ctx context.Context, params models.ListArticlesParams) (int64, error)
+	UpdateStatus(ctx context.Context, article *models.Article) error
+	FindIDBySlug(ctx context.Context, slug string) (string, error)
+	SlugTaken(ctx context.Context, slug string, excludeArticleID string) (bool, error)
+	FindRevisions(ctx context.Context, articleID string) ([]models.ArticleRevision, error)
+	FindRevision(ctx context.Context, articleID string, revision int) (*models.ArticleRevision, error)
 }
 
 type pgxArticleRepo struct {
@@ -31,16 +43,32 @@ func NewPgxArticleRepo(pool *pgxpool.Pool) ArticleRepository {
 }
 
 func (r *pgxArticleRepo) Create(ctx context.Context, article *models.Article) error {
-	query := `INSERT INTO articles (title, body, author_id) VALUES ($1, $2, $3) RETURNING id, created_at, updated_at`
-	row := r.pool.QueryRow(ctx, query, article.Title, article.Body, article.AuthorID)
-	err := row.Scan(&article.ID, &article.CreatedAt, &article.UpdatedAt)
-	return err
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `INSERT INTO articles (title, slug, body, author_id, status, published_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at, updated_at`
+	row := tx.QueryRow(ctx, query, article.Title, article.Slug, article.Body, article.AuthorID, article.Status, article.PublishedAt)
+	if err := row.Scan(&article.ID, &article.CreatedAt, &article.UpdatedAt); err != nil {
+		return slugError(err)
+	}
+
+	if err := setArticleTags(ctx, tx, article.ID, article.Tags); err != nil {
+		return err
+	}
+	if err := insertRevision(ctx, tx, article, article.AuthorID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 func (r *pgxArticleRepo) FindByID(ctx context.Context, id string) (*models.Article, error) {
 	query := `
 		SELECT
-			a.id, a.title, a.body, a.author_id, a.created_at, a.updated_at,
+			a.id, a.title, a.slug, a.body, a.author_id, a.status, a.published_at, a.created_at, a.updated_at,
+			ARRAY(SELECT t.name FROM article_tags atg JOIN tags t ON t.id = atg.tag_id WHERE atg.article_id = a.id ORDER BY t.name),
 			u.username as author_username, u.name as author_name, u.created_at as author_created_at, u.updated_at as author_updated_at
 		FROM articles a
 		JOIN users u ON a.author_id = u.id
@@ -51,7 +79,8 @@ func (r *pgxArticleRepo) FindByID(ctx context.Context, id string) (*models.Artic
 	var article models.Article
 	var author models.UserResponse
 	err := row.Scan(
-		&article.ID, &article.Title, &article.Body, &article.AuthorID, &article.CreatedAt, &article.UpdatedAt,
+		&article.ID, &article.Title, &article.Slug, &article.Body, &article.AuthorID, &article.Status, &article.PublishedAt, &article.CreatedAt, &article.UpdatedAt,
+		&article.Tags,
 		&author.Username, &author.Name, &author.CreatedAt, &author.UpdatedAt,
 	)
 	if err != nil {
@@ -69,28 +98,15 @@ func (r *pgxArticleRepo) FindAll(ctx context.Context, params models.ListArticles
 	var queryBuilder strings.Builder
 	queryBuilder.WriteString(`
 		SELECT
-			a.id, a.title, a.body, a.author_id, a.created_at, a.updated_at,
+			a.id, a.title, a.slug, a.body, a.author_id, a.status, a.published_at, a.created_at, a.updated_at,
+			ARRAY(SELECT t.name FROM article_tags atg JOIN tags t ON t.id = atg.tag_id WHERE atg.article_id = a.id ORDER BY t.name),
 			u.username, u.name, u.created_at, u.updated_at
 		FROM articles a
 		JOIN users u ON a.author_id = u.id
 	`)
 
-	var args []interface{}
-	var conditions []string
-
-	if params.Author != "" {
-		args = append(args, params.Author)
-		conditions = append(conditions, fmt.Sprintf("LOWER(u.name) = LOWER($%d)", len(args)))
-	}
-	if params.Query != "" {
-		searchQuery := strings.Join(strings.Fields(params.Query), " & ")
-		args = append(args, searchQuery)
-		conditions = append(conditions, fmt.Sprintf("a.search_vector @@ to_tsquery('english', $%d)", len(args)))
-	}
-
-	if len(conditions) > 0 {
-		queryBuilder.WriteString(" WHERE " + strings.Join(conditions, " AND "))
-	}
+	conditions, args := buildArticleFilters(params)
+	queryBuilder.WriteString(" WHERE " + strings.Join(conditions, " AND "))
 
 	queryBuilder.WriteString(" ORDER BY a.created_at DESC")
 	args = append(args, params.Limit)
@@ -109,7 +125,8 @@ func (r *pgxArticleRepo) FindAll(ctx context.Context, params models.ListArticles
 		var article models.Article
 		var author models.UserResponse
 		err := rows.Scan(
-			&article.ID, &article.Title, &article.Body, &article.AuthorID, &article.CreatedAt, &article.UpdatedAt,
+			&article.ID, &article.Title, &article.Slug, &article.Body, &article.AuthorID, &article.Status, &article.PublishedAt, &article.CreatedAt, &article.UpdatedAt,
+			&article.Tags,
 			&author.Username, &author.Name, &author.CreatedAt, &author.UpdatedAt,
 		)
 		if err != nil {
@@ -131,36 +148,177 @@ func (r *pgxArticleRepo) CountAll(ctx context.Context, params models.ListArticle
 	var queryBuilder strings.Builder
 	queryBuilder.WriteString("SELECT COUNT(*) FROM articles a")
 
-	var args []interface{}
-	var conditions []string
-
 	if params.Author != "" || params.Query != "" {
 		queryBuilder.WriteString(" JOIN users u ON a.author_id = u.id")
 	}
 
-	if params.Author != "" {
-		args = append(args, params.Author)
-		conditions = append(conditions, fmt.Sprintf("LOWER(u.name) = LOWER($%d)", len(args)))
+	conditions, args := buildArticleFilters(params)
+	queryBuilder.WriteString(" WHERE " + strings.Join(conditions, " AND "))
+
+	var count int64
+	err := r.pool.QueryRow(ctx, queryBuilder.String(), args...).Scan(&count)
+	return count, err
+}
+
+func (r *pgxArticleRepo) Update(ctx context.Context, article *models.Article, editorID string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
 	}
-	if params.Query != "" {
-		searchQuery := strings.Join(strings.Fields(params.Query), " & ")
-		args = append(args, searchQuery)
-		conditions = append(conditions, fmt.Sprintf("a.search_vector @@ to_tsquery('english', $%d)", len(args)))
+	defer tx.Rollback(ctx)
+
+	var oldSlug string
+	if err := tx.QueryRow(ctx, `SELECT slug FROM articles WHERE id = $1 FOR UPDATE`, article.ID).Scan(&oldSlug); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrArticleNotFound
+		}
+		return err
 	}
 
-	if len(conditions) > 0 {
-		queryBuilder.WriteString(" WHERE " + strings.Join(conditions, " AND "))
+	query := `UPDATE articles SET title = $1, slug = $2, body = $3 WHERE id = $4 RETURNING updated_at`
+	row := tx.QueryRow(ctx, query, article.Title, article.Slug, article.Body, article.ID)
+	if err := row.Scan(&article.UpdatedAt); err != nil {
+		return slugError(err)
 	}
 
-	var count int64
-	err := r.pool.QueryRow(ctx, queryBuilder.String(), args...).Scan(&count)
-	return count, err
+	if oldSlug != article.Slug {
+		if _, err := tx.Exec(ctx, `DELETE FROM article_slug_redirects WHERE slug = $1`, article.Slug); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO article_slug_redirects (slug, article_id) VALUES ($1, $2)`, oldSlug, article.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := setArticleTags(ctx, tx, article.ID, article.Tags); err != nil {
+		return err
+	}
+	if err := insertRevision(ctx, tx, article, editorID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *pgxArticleRepo) FindIDBySlug(ctx context.Context, slug string) (string, error) {
+	query := `
+		SELECT id::text FROM articles WHERE slug = $1
+		UNION ALL
+		SELECT article_id::text FROM article_slug_redirects WHERE slug = $1
+		LIMIT 1`
+
+	var id string
+	err := r.pool.QueryRow(ctx, query, slug).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrArticleNotFound
+		}
+		return "", err
+	}
+	return id, nil
+}
+
+func (r *pgxArticleRepo) SlugTaken(ctx context.Context, slug string, excludeArticleID string) (bool, error) {
+	query := `
+		SELECT
+			EXISTS (SELECT 1 FROM articles WHERE slug = $1 AND id::text <> $2) OR
+			EXISTS (SELECT 1 FROM article_slug_redirects WHERE slug = $1 AND article_id::text <> $2)`
+
+	var taken bool
+	err := r.pool.QueryRow(ctx, query, slug, excludeArticleID).Scan(&taken)
+	return taken, err
 }
 
-func (r *pgxArticleRepo) Update(ctx context.Context, article *models.Article) error {
-	query := `UPDATE articles SET title = $1, body = $2 WHERE id = $3 RETURNING updated_at`
-	row := r.pool.QueryRow(ctx, query, article.Title, article.Body, article.ID)
+func (r *pgxArticleRepo) FindRevisions(ctx context.Context, articleID string) ([]models.ArticleRevision, error) {
+	query := `
+		SELECT id, article_id, revision, title, body, COALESCE(author_id::text, ''), created_at
+		FROM article_revisions
+		WHERE article_id = $1
+		ORDER BY revision DESC`
+
+	rows, err := r.pool.Query(ctx, query, articleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := make([]models.ArticleRevision, 0)
+	for rows.Next() {
+		var rev models.ArticleRevision
+		if err := rows.Scan(&rev.ID, &rev.ArticleID, &rev.Revision, &rev.Title, &rev.Body, &rev.AuthorID, &rev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan revision row: %w", err)
+		}
+		revisions = append(revisions, rev)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (r *pgxArticleRepo) FindRevision(ctx context.Context, articleID string, revision int) (*models.ArticleRevision, error) {
+	query := `
+		SELECT id, article_id, revision, title, body, COALESCE(author_id::text, ''), created_at
+		FROM article_revisions
+		WHERE article_id = $1 AND revision = $2`
+
+	var rev models.ArticleRevision
+	err := r.pool.QueryRow(ctx, query, articleID, revision).Scan(
+		&rev.ID, &rev.ArticleID, &rev.Revision, &rev.Title, &rev.Body, &rev.AuthorID, &rev.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// slugError turns a unique violation on articles.slug into ErrSlugTaken.
+func slugError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "articles_slug_key" {
+		return ErrSlugTaken
+	}
+	return err
+}
+
+func setArticleTags(ctx context.Context, tx pgx.Tx, articleID string, tags []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM article_tags WHERE article_id = $1`, articleID); err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING`, tags); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `INSERT INTO article_tags (article_id, tag_id) SELECT $1, id FROM tags WHERE name = ANY($2)`, articleID, tags)
+	return err
+}
+
+// insertRevision snapshots the article's current title and body as the next
+// revision number. It must run in the same transaction as the write it records.
+func insertRevision(ctx context.Context, tx pgx.Tx, article *models.Article, editorID string) error {
+	query := `
+		INSERT INTO article_revisions (article_id, revision, title, body, author_id)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4
+		FROM article_revisions
+		WHERE article_id = $1`
+	_, err := tx.Exec(ctx, query, article.ID, article.Title, article.Body, editorID)
+	return err
+}
+
+func (r *pgxArticleRepo) UpdateStatus(ctx context.Context, article *models.Article) error {
+	query := `UPDATE articles SET status = $1, published_at = $2 WHERE id = $3 RETURNING updated_at`
+	row := r.pool.QueryRow(ctx, query, article.Status, article.PublishedAt, article.ID)
 	err := row.Scan(&article.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrArticleNotFound
+	}
 	return err
 }
 
@@ -175,3 +333,48 @@ func (r *pgxArticleRepo) Delete(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+// buildArticleFilters returns the WHERE conditions shared by FindAll and CountAll.
+// Anonymous viewers only see published articles, while an authenticated viewer
+// additionally sees their own drafts and archived articles.
+func buildArticleFilters(params models.ListArticlesParams) ([]string, []interface{}) {
+	var args []interface{}
+	var conditions []string
+
+	if params.ViewerID != "" {
+		args = append(args, models.ArticleStatusPublished, params.ViewerID)
+		conditions = append(conditions, fmt.Sprintf("(a.status = $%d OR a.author_id = $%d)", len(args)-1, len(args)))
+	} else {
+		args = append(args, models.ArticleStatusPublished)
+		conditions = append(conditions, fmt.Sprintf("a.status = $%d", len(args)))
+	}
+
+	if params.Status != "" {
+		args = append(args, params.Status)
+		conditions = append(conditions, fmt.Sprintf("a.status = $%d", len(args)))
+	}
+	if params.Author != "" {
+		args = append(args, params.Author)
+		conditions = append(conditions, fmt.Sprintf("LOWER(u.name) = LOWER($%d)", len(args)))
+	}
+	if params.Query != "" {
+		searchQuery := strings.Join(strings.Fields(params.Query), " & ")
+		args = append(args, searchQuery)
+		conditions = append(conditions, fmt.Sprintf("a.search_vector @@ to_tsquery('english', $%d)", len(args)))
+	}
+	if len(params.Tags) > 0 {
+		args = append(args, params.Tags)
+		if params.TagMode == models.TagModeAll {
+			args = append(args, len(params.Tags))
+			conditions = append(conditions, fmt.Sprintf(
+				"(SELECT COUNT(DISTINCT t.name) FROM article_tags atg JOIN tags t ON t.id = atg.tag_id WHERE atg.article_id = a.id AND t.name = ANY($%d)) = $%d",
+				len(args)-1, len(args)))
+		} else {
+			conditions = append(conditions, fmt.Sprintf(
+				"EXISTS (SELECT 1 FROM article_tags atg JOIN tags t ON t.id = atg.tag_id WHERE atg.article_id = a.id AND t.name = ANY($%d))",
+				len(args)))
+		}
+	}
+
+	return conditions, args
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildArticleFilters_Visibility(t *testing.T) {
+	tests := []struct {
+		name           string
+		params         models.ListArticlesParams
+		wantConditions []string
+		wantArgs       []interface{}
+	}{
+		{
+			name:           "publik hanya melihat artikel terbit",
+			params:         models.ListArticlesParams{},
+			wantConditions: []string{"a.status = $1"},
+			wantArgs:       []interface{}{models.ArticleStatusPublished},
+		},
+		{
+			name:           "pengguna juga melihat draft dan arsip miliknya",
+			params:         models.ListArticlesParams{ViewerID: "user-1"},
+			wantConditions: []string{"(a.status = $1 OR a.author_id = $2)"},
+			wantArgs:       []interface{}{models.ArticleStatusPublished, "user-1"},
+		},
+		{
+			name:   "filter status tidak membuka draft milik orang lain",
+			params: models.ListArticlesParams{ViewerID: "user-1", Status: models.ArticleStatusDraft},
+			wantConditions: []string{
+				"(a.status = $1 OR a.author_id = $2)",
+				"a.status = $3",
+			},
+			wantArgs: []interface{}{models.ArticleStatusPublished, "user-1", models.ArticleStatusDraft},
+		},
+		{
+			name:   "publik yang meminta draft tidak mendapat apa pun",
+			params: models.ListArticlesParams{Status: models.ArticleStatusDraft},
+			wantConditions: []string{
+				"a.status = $1",
+				"a.status = $2",
+			},
+			wantArgs: []interface{}{models.ArticleStatusPublished, models.ArticleStatusDraft},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conditions, args := buildArticleFilters(tt.params)
+			assert.Equal(t, tt.wantConditions, conditions)
+			assert.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
+
+func TestBuildArticleFilters_Tags(t *testing.T) {
+	t.Run("mode any cukup salah satu tag", func(t *testing.T) {
+		conditions, args := buildArticleFilters(models.ListArticlesParams{Tags: []string{"go", "redis"}, TagMode: models.TagModeAny})
+		assert.Equal(t, []string{
+			"a.status = $1",
+			"EXISTS (SELECT 1 FROM article_tags atg JOIN tags t ON t.id = atg.tag_id WHERE atg.article_id = a.id AND t.name = ANY($2))",
+		}, conditions)
+		assert.Equal(t, []interface{}{models.ArticleStatusPublished, []string{"go", "redis"}}, args)
+	})
+
+	t.Run("mode all membutuhkan semua tag", func(t *testing.T) {
+		conditions, args := buildArticleFilters(models.ListArticlesParams{Tags: []string{"go", "redis"}, TagMode: models.TagModeAll})
+		assert.Equal(t, []string{
+			"a.status = $1",
+			"(SELECT COUNT(DISTINCT t.name) FROM article_tags atg JOIN tags t ON t.id = atg.tag_id WHERE atg.article_id = a.id AND t.name = ANY($2)) = $3",
+		}, conditions)
+		assert.Equal(t, []interface{}{models.ArticleStatusPublished, []string{"go", "redis"}, 2}, args)
+	})
+
+	t.Run("tanpa tag tidak ada filter tag", func(t *testing.T) {
+		conditions, _ := buildArticleFilters(models.ListArticlesParams{TagMode: models.TagModeAll})
+		assert.Equal(t, []string{"a.status = $1"}, conditions)
+	})
+}
@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrCommentNotFound = errors.New("comment not found")
+
+type CommentRepository interface {
+	Create(ctx context.Context, comment *models.Comment) error
+	FindByID(ctx context.Context, id string) (*models.Comment, error)
+	FindByArticleID(ctx context.Context, params models.ListCommentsParams) ([]models.Comment, error)
+	CountByArticleID(ctx context.Context, articleID string) (int64, error)
+	Update(ctx context.Context, comment *models.Comment) error
+	Delete(ctx context.Context, id string) error
+}
+
+type pgxCommentRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewPgxCommentRepo(pool *pgxpool.Pool) CommentRepository {
+	return &pgxCommentRepo{pool: pool}
+}
+
+const commentColumns = `
+	c.id, c.article_id, c.parent_id, c.author_id, c.body, c.created_at, c.updated_at,
+	u.username, u.name, u.created_at, u.updated_at`
+
+func (r *pgxCommentRepo) Create(ctx context.Context, comment *models.Comment) error {
+	query := `INSERT INTO comments (article_id, parent_id, author_id, body) VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at`
+	row := r.pool.QueryRow(ctx, query, comment.ArticleID, comment.ParentID, comment.AuthorID, comment.Body)
+	return row.Scan(&comment.ID, &comment.CreatedAt, &comment.UpdatedAt)
+}
+
+func (r *pgxCommentRepo) FindByID(ctx context.Context, id string) (*models.Comment, error) {
+	query := `SELECT` + commentColumns + `
+		FROM comments c
+		JOIN users u ON c.author_id = u.id
+		WHERE c.id = $1`
+
+	comment, err := scanComment(r.pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (r *pgxCommentRepo) FindByArticleID(ctx context.Context, params models.ListCommentsParams) ([]models.Comment, error) {
+	query := `SELECT` + commentColumns + `
+		FROM comments c
+		JOIN users u ON c.author_id = u.id
+		WHERE c.article_id = $1 AND c.parent_id IS NULL
+		ORDER BY c.created_at ASC
+		LIMIT $2 OFFSET $3`
+
+	comments, err := r.queryComments(ctx, query, params.ArticleID, params.Limit, params.Offset)
+	if err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return comments, nil
+	}
+
+	ids := make([]string, len(comments))
+	index := make(map[string]int, len(comments))
+	for i, c := range comments {
+		ids[i] = c.ID
+		index[c.ID] = i
+	}
+
+	repliesQuery := `SELECT` + commentColumns + `
+		FROM comments c
+		JOIN users u ON c.author_id = u.id
+		WHERE c.parent_id = ANY($1::uuid[])
+		ORDER BY c.created_at ASC`
+
+	replies, err := r.queryComments(ctx, repliesQuery, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, reply := range replies {
+		parent := &comments[index[*reply.ParentID]]
+		parent.Replies = append(parent.Replies, reply)
+	}
+
+	return comments, nil
+}
+
+func (r *pgxCommentRepo) CountByArticleID(ctx context.Context, articleID string) (int64, error) {
+	query := `SELECT COUNT(*) FROM comments WHERE article_id = $1 AND parent_id IS NULL`
+	var count int64
+	err := r.pool.QueryRow(ctx, query, articleID).Scan(&count)
+	return count, err
+}
+
+func (r *pgxCommentRepo) Update(ctx context.Context, comment *models.Comment) error {
+	query := `UPDATE comments SET body = $1 WHERE id = $2 RETURNING updated_at`
+	err := r.pool.QueryRow(ctx, query, comment.Body, comment.ID).Scan(&comment.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrCommentNotFound
+	}
+	return err
+}
+
+func (r *pgxCommentRepo) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM comments WHERE id = $1`
+	cmdTag, err := r.pool.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrCommentNotFound
+	}
+	return nil
+}
+
+func (r *pgxCommentRepo) queryComments(ctx context.Context, query string, args ...interface{}) ([]models.Comment, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comments: %w", err)
+	}
+	defer rows.Close()
+
+	comments := make([]models.Comment, 0)
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan comment row: %w", err)
+		}
+		comments = append(comments, *comment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %w", err)
+	}
+	return comments, nil
+}
+
+func scanComment(row pgx.Row) (*models.Comment, error) {
+	var comment models.Comment
+	var author models.UserResponse
+	err := row.Scan(
+		&comment.ID, &comment.ArticleID, &comment.ParentID, &comment.AuthorID, &comment.Body, &comment.CreatedAt, &comment.UpdatedAt,
+		&author.Username, &author.Name, &author.CreatedAt, &author.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	author.ID = comment.AuthorID
+	comment.Author = &author
+	return &comment, nil
+}
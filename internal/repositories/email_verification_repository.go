@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrEmailVerificationTokenInvalid = errors.New("email verification token is invalid or expired")
+
+type EmailVerificationRepository interface {
+	Create(ctx context.Context, userID string, email string, tokenHash string, expiresAt time.Time) error
+	Consume(ctx context.Context, tokenHash string) (userID string, email string, err error)
+}
+
+type pgxEmailVerificationRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewPgxEmailVerificationRepo(pool *pgxpool.Pool) EmailVerificationRepository {
+	return &pgxEmailVerificationRepo{pool: pool}
+}
+
+func (r *pgxEmailVerificationRepo) Create(ctx context.Context, userID string, email string, tokenHash string, expiresAt time.Time) error {
+	query := `INSERT INTO email_verification_tokens (token_hash, user_id, email, expires_at) VALUES ($1, $2, $3, $4)`
+	_, err := r.pool.Exec(ctx, query, tokenHash, userID, email, expiresAt)
+	return err
+}
+
+// Consume deletes the token and returns the user and the address it was sent
+// to. An expired token is deleted as well but reported as invalid.
+func (r *pgxEmailVerificationRepo) Consume(ctx context.Context, tokenHash string) (string, string, error) {
+	query := `DELETE FROM email_verification_tokens WHERE token_hash = $1 RETURNING user_id, email, expires_at`
+
+	var userID, email string
+	var expiresAt time.Time
+	err := r.pool.QueryRow(ctx, query, tokenHash).Scan(&userID, &email, &expiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", "", ErrEmailVerificationTokenInvalid
+		}
+		return "", "", err
+	}
+	if time.Now().After(expiresAt) {
+		return "", "", ErrEmailVerificationTokenInvalid
+	}
+	return userID, email, nil
+}
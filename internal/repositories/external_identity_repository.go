@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+)
+
+var ErrExternalIdentityNotFound = errors.New("external identity not found")
+
+type ExternalIdentityRepository interface {
+	Find(ctx context.Context, provider string, subject string) (*models.ExternalIdentity, error)
+	Create(ctx context.Context, identity *models.ExternalIdentity) error
+}
+
+type pgxExternalIdentityRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewPgxExternalIdentityRepo(pool *pgxpool.Pool) ExternalIdentityRepository {
+	return &pgxExternalIdentityRepo{pool: pool}
+}
+
+func (r *pgxExternalIdentityRepo) Find(ctx context.Context, provider string, subject string) (*models.ExternalIdentity, error) {
+	query := `SELECT provider, subject, user_id, COALESCE(email, ''), created_at FROM user_external_identities WHERE provider = $1 AND subject = $2`
+
+	var identity models.ExternalIdentity
+	err := r.pool.QueryRow(ctx, query, provider, subject).Scan(&identity.Provider, &identity.Subject, &identity.UserID, &identity.Email, &identity.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrExternalIdentityNotFound
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *pgxExternalIdentityRepo) Create(ctx context.Context, identity *models.ExternalIdentity) error {
+	query := `INSERT INTO user_external_identities (provider, subject, user_id, email) VALUES ($1, $2, $3, NULLIF($4, '')) RETURNING created_at`
+	return r.pool.QueryRow(ctx, query, identity.Provider, identity.Subject, identity.UserID, identity.Email).Scan(&identity.CreatedAt)
+}
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/dhifanrazaqa/kumparan-article/pkg/cache"
+)
+
+// LoginAttemptRepository keeps failed login counters and temporary locks.
+// Keys are opaque to the repository, e.g. "user:budi" or "ip:10.0.0.1".
+type LoginAttemptRepository interface {
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	RecordFailure(ctx context.Context, key string, window time.Duration) (int64, error)
+	Lock(ctx context.Context, key string, duration time.Duration) error
+	Reset(ctx context.Context, key string) error
+}
+
+type loginAttemptRepo struct {
+	store cache.Cache
+}
+
+// NewLoginAttemptRepo keeps login attempts in store, which must not evict
+// keys before they expire: an evicted lock is a lifted lock.
+func NewLoginAttemptRepo(store cache.Cache) LoginAttemptRepository {
+	return &loginAttemptRepo{store: store}
+}
+
+func (r *loginAttemptRepo) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	return r.store.TTL(ctx, loginLockKey(key))
+}
+
+// RecordFailure counts a failed attempt and returns the number of failures
+// within the sliding window, which restarts with every failure.
+func (r *loginAttemptRepo) RecordFailure(ctx context.Context, key string, window time.Duration) (int64, error) {
+	return r.store.Incr(ctx, loginFailuresKey(key), window)
+}
+
+func (r *loginAttemptRepo) Lock(ctx context.Context, key string, duration time.Duration) error {
+	return r.store.Set(ctx, loginLockKey(key), []byte("1"), duration)
+}
+
+func (r *loginAttemptRepo) Reset(ctx context.Context, key string) error {
+	return r.store.Delete(ctx, loginFailuresKey(key), loginLockKey(key))
+}
+
+func loginFailuresKey(key string) string {
+	return "login_failures:" + key
+}
+
+func loginLockKey(key string) string {
+	return "login_lock:" + key
+}
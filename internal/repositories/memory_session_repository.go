@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/cache"
+)
+
+// memorySessionRepo keeps the same documents and indexes as redisSessionRepo
+// in a process-local cache. The mutex takes the place of Redis transactions,
+// so it only works for a single instance.
+type memorySessionRepo struct {
+	mu           sync.Mutex
+	store        cache.Cache
+	userSessions map[string]map[string]struct{}
+}
+
+// NewMemorySessionRepo returns a process-local SessionRepository, intended
+// for tests and single-instance development setups.
+func NewMemorySessionRepo() SessionRepository {
+	return &memorySessionRepo{
+		store:        cache.NewLRU(0),
+		userSessions: make(map[string]map[string]struct{}),
+	}
+}
+
+func (r *memorySessionRepo) Create(ctx context.Context, session *models.Session, ttl time.Duration) error {
+	data, err := json.Marshal(toSessionRecord(session))
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.store.Set(ctx, sessionKey(session.ID), data, ttl)
+	r.store.Set(ctx, refreshTokenKey(session.RefreshTokenID), []byte(session.ID), ttl)
+	if r.userSessions[session.UserID] == nil {
+		r.userSessions[session.UserID] = make(map[string]struct{})
+	}
+	r.userSessions[session.UserID][session.ID] = struct{}{}
+	return nil
+}
+
+func (r *memorySessionRepo) FindByID(ctx context.Context, id string) (*models.Session, error) {
+	val, err := r.store.Get(ctx, sessionKey(id))
+	if errors.Is(err, cache.ErrNotFound) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeSession(string(val))
+}
+
+func (r *memorySessionRepo) FindByRefreshTokenID(ctx context.Context, refreshTokenID string) (*models.Session, error) {
+	return r.findByIndex(ctx, refreshTokenKey(refreshTokenID))
+}
+
+func (r *memorySessionRepo) FindByRotatedTokenID(ctx context.Context, refreshTokenID string) (*models.Session, error) {
+	return r.findByIndex(ctx, rotatedTokenKey(refreshTokenID))
+}
+
+func (r *memorySessionRepo) findByIndex(ctx context.Context, key string) (*models.Session, error) {
+	id, err := r.store.Get(ctx, key)
+	if errors.Is(err, cache.ErrNotFound) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, string(id))
+}
+
+func (r *memorySessionRepo) FindByUserID(ctx context.Context, userID string) ([]models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.findByUserID(ctx, userID)
+}
+
+// findByUserID must be called with r.mu held.
+func (r *memorySessionRepo) findByUserID(ctx context.Context, userID string) ([]models.Session, error) {
+	sessions := make([]models.Session, 0, len(r.userSessions[userID]))
+	for id := range r.userSessions[userID] {
+		session, err := r.FindByID(ctx, id)
+		if errors.Is(err, ErrSessionNotFound) {
+			delete(r.userSessions[userID], id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	if len(r.userSessions[userID]) == 0 {
+		delete(r.userSessions, userID)
+	}
+	return sessions, nil
+}
+
+// Rotate replaces the current refresh token of the session. It fails with
+// ErrSessionNotFound when oldRefreshTokenID is no longer the current token.
+func (r *memorySessionRepo) Rotate(ctx context.Context, session *models.Session, oldRefreshTokenID string, ttl time.Duration) error {
+	data, err := json.Marshal(toSessionRecord(session))
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, err := r.store.Get(ctx, refreshTokenKey(oldRefreshTokenID))
+	if err != nil && !errors.Is(err, cache.ErrNotFound) {
+		return err
+	}
+	if string(current) != session.ID {
+		return ErrSessionNotFound
+	}
+
+	r.store.Delete(ctx, refreshTokenKey(oldRefreshTokenID))
+	r.store.Set(ctx, rotatedTokenKey(oldRefreshTokenID), []byte(session.ID), ttl)
+	r.store.Set(ctx, sessionKey(session.ID), data, ttl)
+	r.store.Set(ctx, refreshTokenKey(session.RefreshTokenID), []byte(session.ID), ttl)
+	return nil
+}
+
+func (r *memorySessionRepo) Delete(ctx context.Context, session *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.store.Delete(ctx, sessionKey(session.ID), refreshTokenKey(session.RefreshTokenID))
+	delete(r.userSessions[session.UserID], session.ID)
+	return nil
+}
+
+func (r *memorySessionRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sessions, err := r.findByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		r.store.Delete(ctx, sessionKey(session.ID), refreshTokenKey(session.RefreshTokenID))
+	}
+	delete(r.userSessions, userID)
+	return nil
+}
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/cache"
+)
+
+var ErrOIDCStateNotFound = errors.New("oidc login state not found")
+
+// OIDCStateRepository keeps the nonce and PKCE verifier of logins that are
+// waiting for the identity provider to redirect back.
+type OIDCStateRepository interface {
+	Save(ctx context.Context, state string, login *models.OIDCLoginState, ttl time.Duration) error
+	Consume(ctx context.Context, state string) (*models.OIDCLoginState, error)
+}
+
+type oidcStateRepo struct {
+	store cache.Cache
+}
+
+func NewOIDCStateRepo(store cache.Cache) OIDCStateRepository {
+	return &oidcStateRepo{store: store}
+}
+
+func (r *oidcStateRepo) Save(ctx context.Context, state string, login *models.OIDCLoginState, ttl time.Duration) error {
+	data, err := json.Marshal(login)
+	if err != nil {
+		return err
+	}
+	return r.store.Set(ctx, oidcStateKey(state), data, ttl)
+}
+
+// Consume returns the login state and deletes it atomically, so a state can
+// complete at most one login.
+func (r *oidcStateRepo) Consume(ctx context.Context, state string) (*models.OIDCLoginState, error) {
+	data, err := r.store.Take(ctx, oidcStateKey(state))
+	if errors.Is(err, cache.ErrNotFound) {
+		return nil, ErrOIDCStateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var login models.OIDCLoginState
+	if err := json.Unmarshal(data, &login); err != nil {
+		return nil, err
+	}
+	return &login, nil
+}
+
+func oidcStateKey(state string) string {
+	return "oidc_state:" + state
+}
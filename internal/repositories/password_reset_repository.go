@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrPasswordResetTokenInvalid = errors.New("password reset token is invalid or expired")
+
+// PasswordResetRepository stores only hashes of reset tokens; the plain token
+// exists solely in the email sent to the user.
+type PasswordResetRepository interface {
+	Create(ctx context.Context, userID string, tokenHash string, expiresAt time.Time) error
+	Find(ctx context.Context, tokenHash string) (string, error)
+	Consume(ctx context.Context, tokenHash string, hashedPassword string) (string, error)
+	CountRecent(ctx context.Context, userID string, since time.Time) (int, error)
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+type pgxPasswordResetRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewPgxPasswordResetRepo(pool *pgxpool.Pool) PasswordResetRepository {
+	return &pgxPasswordResetRepo{pool: pool}
+}
+
+func (r *pgxPasswordResetRepo) Create(ctx context.Context, userID string, tokenHash string, expiresAt time.Time) error {
+	query := `INSERT INTO password_reset_tokens (token_hash, user_id, expires_at) VALUES ($1, $2, $3)`
+	_, err := r.pool.Exec(ctx, query, tokenHash, userID, expiresAt)
+	return err
+}
+
+// Find returns the user of a token that is still unused and unexpired
+// without spending it.
+func (r *pgxPasswordResetRepo) Find(ctx context.Context, tokenHash string) (string, error) {
+	query := `SELECT user_id FROM password_reset_tokens WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()`
+
+	var userID string
+	err := r.pool.QueryRow(ctx, query, tokenHash).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrPasswordResetTokenInvalid
+		}
+		return "", err
+	}
+	return userID, nil
+}
+
+// Consume marks the token as used and sets its user's password in one
+// transaction, and returns the user. The conditional update makes sure two
+// concurrent requests cannot both use the same token.
+func (r *pgxPasswordResetRepo) Consume(ctx context.Context, tokenHash string, hashedPassword string) (string, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE password_reset_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id`
+
+	var userID string
+	if err := tx.QueryRow(ctx, query, tokenHash).Scan(&userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrPasswordResetTokenInvalid
+		}
+		return "", err
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE users SET hashed_password = $1 WHERE id = $2`, hashedPassword, userID); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return userID, nil
+}
+
+// CountRecent returns how many tokens were issued to the user since the given
+// time, used or not.
+func (r *pgxPasswordResetRepo) CountRecent(ctx context.Context, userID string, since time.Time) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM password_reset_tokens WHERE user_id = $1 AND created_at > $2`, userID, since).Scan(&count)
+	return count, err
+}
+
+func (r *pgxPasswordResetRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM password_reset_tokens WHERE user_id = $1`, userID)
+	return err
+}
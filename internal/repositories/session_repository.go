@@ -0,0 +1,271 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session, ttl time.Duration) error
+	FindByID(ctx context.Context, id string) (*models.Session, error)
+	FindByRefreshTokenID(ctx context.Context, refreshTokenID string) (*models.Session, error)
+	FindByRotatedTokenID(ctx context.Context, refreshTokenID string) (*models.Session, error)
+	FindByUserID(ctx context.Context, userID string) ([]models.Session, error)
+	Rotate(ctx context.Context, session *models.Session, oldRefreshTokenID string, ttl time.Duration) error
+	Delete(ctx context.Context, session *models.Session) error
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+// redisSessionRepo stores one JSON document per session plus three indexes:
+// the current refresh token of each session, the refresh tokens it already
+// rotated away from, and the set of sessions per user. A session is the
+// rotation family of all refresh tokens issued for it. The keys of a session
+// hash to different cluster slots, so no command touches more than one key.
+type redisSessionRepo struct {
+	client redis.UniversalClient
+}
+
+func NewRedisSessionRepo(client redis.UniversalClient) SessionRepository {
+	return &redisSessionRepo{client: client}
+}
+
+type sessionRecord struct {
+	ID             string    `json:"id"`
+	UserID         string    `json:"userId"`
+	RefreshTokenID string    `json:"refreshTokenId"`
+	UserAgent      string    `json:"userAgent"`
+	IP             string    `json:"ip"`
+	CreatedAt      time.Time `json:"createdAt"`
+	LastUsedAt     time.Time `json:"lastUsedAt"`
+	// Scopes is missing only from sessions created before scopes existed.
+	Scopes []string `json:"scopes"`
+}
+
+func (r *redisSessionRepo) Create(ctx context.Context, session *models.Session, ttl time.Duration) error {
+	data, err := json.Marshal(toSessionRecord(session))
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, sessionKey(session.ID), data, ttl)
+		pipe.Set(ctx, refreshTokenKey(session.RefreshTokenID), session.ID, ttl)
+		pipe.SAdd(ctx, userSessionsKey(session.UserID), session.ID)
+		pipe.Expire(ctx, userSessionsKey(session.UserID), ttl)
+		return nil
+	})
+	return err
+}
+
+func (r *redisSessionRepo) FindByID(ctx context.Context, id string) (*models.Session, error) {
+	val, err := r.client.Get(ctx, sessionKey(id)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeSession(val)
+}
+
+func (r *redisSessionRepo) FindByRefreshTokenID(ctx context.Context, refreshTokenID string) (*models.Session, error) {
+	id, err := r.client.Get(ctx, refreshTokenKey(refreshTokenID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+func (r *redisSessionRepo) FindByRotatedTokenID(ctx context.Context, refreshTokenID string) (*models.Session, error) {
+	id, err := r.client.Get(ctx, rotatedTokenKey(refreshTokenID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+func (r *redisSessionRepo) FindByUserID(ctx context.Context, userID string) ([]models.Session, error) {
+	ids, err := r.client.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]models.Session, 0, len(ids))
+	if len(ids) == 0 {
+		return sessions, nil
+	}
+
+	gets := make([]*redis.StringCmd, len(ids))
+	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			gets[i] = pipe.Get(ctx, sessionKey(id))
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	var expired []interface{}
+	for i, get := range gets {
+		val, err := get.Result()
+		if errors.Is(err, redis.Nil) {
+			expired = append(expired, ids[i])
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		session, err := decodeSession(val)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	if len(expired) > 0 {
+		r.client.SRem(ctx, userSessionsKey(userID), expired...)
+	}
+
+	return sessions, nil
+}
+
+// Rotate replaces the current refresh token of the session. It fails with
+// ErrSessionNotFound when oldRefreshTokenID is no longer the current token,
+// which happens when the same token is presented twice concurrently: taking
+// the old token with GETDEL (Redis 6.2 or newer) lets exactly one of the
+// requests proceed. It also fails when the session is deleted while it runs,
+// as the session document is only rewritten while it still exists, so a
+// logout racing the rotation is never undone.
+//
+// The new token and the rotated marker are written before the old token is
+// taken, so a failure halfway cannot spend the old token without storing the
+// new one and the marker that exposes reuse of the old one. Rotate ignores
+// cancellation of ctx for the same reason; the Redis client's timeouts still
+// bound it.
+func (r *redisSessionRepo) Rotate(ctx context.Context, session *models.Session, oldRefreshTokenID string, ttl time.Duration) error {
+	ctx = context.WithoutCancel(ctx)
+	data, err := json.Marshal(toSessionRecord(session))
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, refreshTokenKey(session.RefreshTokenID), session.ID, ttl)
+		pipe.Set(ctx, rotatedTokenKey(oldRefreshTokenID), session.ID, ttl)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	current, err := r.client.GetDel(ctx, refreshTokenKey(oldRefreshTokenID)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	if current != session.ID {
+		// Another request rotated the token first; the new token was never
+		// handed out. The rotated marker stays, as the old token is spent.
+		r.client.Del(ctx, refreshTokenKey(session.RefreshTokenID))
+		return ErrSessionNotFound
+	}
+
+	stored, err := r.client.SetXX(ctx, sessionKey(session.ID), data, ttl).Result()
+	if err != nil {
+		return err
+	}
+	if !stored {
+		// The session was deleted since the old token was read.
+		r.client.Del(ctx, refreshTokenKey(session.RefreshTokenID))
+		return ErrSessionNotFound
+	}
+	return r.client.Expire(ctx, userSessionsKey(session.UserID), ttl).Err()
+}
+
+func (r *redisSessionRepo) Delete(ctx context.Context, session *models.Session) error {
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, sessionKey(session.ID))
+		pipe.Del(ctx, refreshTokenKey(session.RefreshTokenID))
+		pipe.SRem(ctx, userSessionsKey(session.UserID), session.ID)
+		return nil
+	})
+	return err
+}
+
+func (r *redisSessionRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	sessions, err := r.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, session := range sessions {
+			pipe.Del(ctx, sessionKey(session.ID))
+			pipe.Del(ctx, refreshTokenKey(session.RefreshTokenID))
+		}
+		pipe.Del(ctx, userSessionsKey(userID))
+		return nil
+	})
+	return err
+}
+
+func toSessionRecord(session *models.Session) sessionRecord {
+	return sessionRecord{
+		ID:             session.ID,
+		UserID:         session.UserID,
+		RefreshTokenID: session.RefreshTokenID,
+		UserAgent:      session.UserAgent,
+		IP:             session.IP,
+		CreatedAt:      session.CreatedAt,
+		LastUsedAt:     session.LastUsedAt,
+		Scopes:         append([]string{}, session.Scopes...),
+	}
+}
+
+func decodeSession(val string) (*models.Session, error) {
+	var record sessionRecord
+	if err := json.Unmarshal([]byte(val), &record); err != nil {
+		return nil, err
+	}
+	if record.Scopes == nil {
+		record.Scopes = append([]string(nil), models.AllScopes...)
+	}
+	return &models.Session{
+		ID:             record.ID,
+		UserID:         record.UserID,
+		RefreshTokenID: record.RefreshTokenID,
+		UserAgent:      record.UserAgent,
+		IP:             record.IP,
+		CreatedAt:      record.CreatedAt,
+		LastUsedAt:     record.LastUsedAt,
+		Scopes:         record.Scopes,
+	}, nil
+}
+
+func sessionKey(id string) string {
+	return "session:" + id
+}
+
+func refreshTokenKey(tokenID string) string {
+	return "refresh_token:" + tokenID
+}
+
+func rotatedTokenKey(tokenID string) string {
+	return "rotated_refresh_token:" + tokenID
+}
+
+func userSessionsKey(userID string) string {
+	return "user_sessions:" + userID
+}
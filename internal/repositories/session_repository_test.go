@@ -0,0 +1,285 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSessionRepository runs the behaviour every SessionRepository must share
+// against newRepo. advance lets the sessions of a repository expire.
+func testSessionRepository(t *testing.T, newRepo func(t *testing.T) SessionRepository, advance func(t *testing.T, d time.Duration)) {
+	ctx := context.Background()
+	newSession := func(id, userID, refreshTokenID string) *models.Session {
+		now := time.Now().UTC().Truncate(time.Second)
+		return &models.Session{
+			ID:             id,
+			UserID:         userID,
+			RefreshTokenID: refreshTokenID,
+			UserAgent:      "curl/8.0",
+			IP:             "203.0.113.5",
+			CreatedAt:      now,
+			LastUsedAt:     now,
+			Scopes:         []string{models.ScopeArticlesWrite},
+		}
+	}
+
+	t.Run("sesi baru bisa dicari lewat ID, refresh token dan user", func(t *testing.T) {
+		repo := newRepo(t)
+		session := newSession("session-1", "user-1", "token-1")
+		require.NoError(t, repo.Create(ctx, session, time.Hour))
+
+		byID, err := repo.FindByID(ctx, "session-1")
+		require.NoError(t, err)
+		assert.Equal(t, session, byID)
+
+		byToken, err := repo.FindByRefreshTokenID(ctx, "token-1")
+		require.NoError(t, err)
+		assert.Equal(t, "session-1", byToken.ID)
+
+		sessions, err := repo.FindByUserID(ctx, "user-1")
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, "session-1", sessions[0].ID)
+	})
+
+	t.Run("sesi tanpa scope tetap tanpa scope", func(t *testing.T) {
+		repo := newRepo(t)
+		session := newSession("session-1", "user-1", "token-1")
+		session.Scopes = nil
+		require.NoError(t, repo.Create(ctx, session, time.Hour))
+
+		found, err := repo.FindByID(ctx, "session-1")
+		require.NoError(t, err)
+		assert.Empty(t, found.Scopes)
+	})
+
+	t.Run("rotasi mengganti refresh token dan menandai token lama", func(t *testing.T) {
+		repo := newRepo(t)
+		session := newSession("session-1", "user-1", "token-1")
+		require.NoError(t, repo.Create(ctx, session, time.Hour))
+
+		session.RefreshTokenID = "token-2"
+		require.NoError(t, repo.Rotate(ctx, session, "token-1", time.Hour))
+
+		_, err := repo.FindByRefreshTokenID(ctx, "token-1")
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+		current, err := repo.FindByRefreshTokenID(ctx, "token-2")
+		require.NoError(t, err)
+		assert.Equal(t, "token-2", current.RefreshTokenID)
+		rotated, err := repo.FindByRotatedTokenID(ctx, "token-1")
+		require.NoError(t, err)
+		assert.Equal(t, "session-1", rotated.ID)
+	})
+
+	t.Run("rotasi ulang dari token yang sudah dirotasi ditolak", func(t *testing.T) {
+		repo := newRepo(t)
+		session := newSession("session-1", "user-1", "token-1")
+		require.NoError(t, repo.Create(ctx, session, time.Hour))
+		session.RefreshTokenID = "token-2"
+		require.NoError(t, repo.Rotate(ctx, session, "token-1", time.Hour))
+
+		reused := newSession("session-1", "user-1", "token-3")
+		err := repo.Rotate(ctx, reused, "token-1", time.Hour)
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+
+		_, err = repo.FindByRefreshTokenID(ctx, "token-3")
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+		current, err := repo.FindByID(ctx, "session-1")
+		require.NoError(t, err)
+		assert.Equal(t, "token-2", current.RefreshTokenID)
+	})
+
+	t.Run("rotasi sesi yang tidak dikenal ditolak", func(t *testing.T) {
+		repo := newRepo(t)
+		err := repo.Rotate(ctx, newSession("session-1", "user-1", "token-2"), "token-1", time.Hour)
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+
+		_, err = repo.FindByID(ctx, "session-1")
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+	})
+
+	t.Run("hapus per user hanya mengakhiri sesi user itu", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.Create(ctx, newSession("session-1", "user-1", "token-1"), time.Hour))
+		require.NoError(t, repo.Create(ctx, newSession("session-2", "user-1", "token-2"), time.Hour))
+		require.NoError(t, repo.Create(ctx, newSession("session-3", "user-2", "token-3"), time.Hour))
+
+		require.NoError(t, repo.DeleteByUserID(ctx, "user-1"))
+
+		for _, token := range []string{"token-1", "token-2"} {
+			_, err := repo.FindByRefreshTokenID(ctx, token)
+			assert.ErrorIs(t, err, ErrSessionNotFound)
+		}
+		sessions, err := repo.FindByUserID(ctx, "user-1")
+		require.NoError(t, err)
+		assert.Empty(t, sessions)
+		other, err := repo.FindByRefreshTokenID(ctx, "token-3")
+		require.NoError(t, err)
+		assert.Equal(t, "session-3", other.ID)
+	})
+
+	t.Run("sesi kedaluwarsa tidak ditemukan lagi", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.Create(ctx, newSession("session-1", "user-1", "token-1"), 50*time.Millisecond))
+		require.NoError(t, repo.Create(ctx, newSession("session-2", "user-1", "token-2"), time.Hour))
+
+		advance(t, 100*time.Millisecond)
+
+		_, err := repo.FindByID(ctx, "session-1")
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+		_, err = repo.FindByRefreshTokenID(ctx, "token-1")
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+		sessions, err := repo.FindByUserID(ctx, "user-1")
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, "session-2", sessions[0].ID)
+	})
+}
+
+func TestMemorySessionRepo(t *testing.T) {
+	testSessionRepository(t,
+		func(t *testing.T) SessionRepository { return NewMemorySessionRepo() },
+		func(t *testing.T, d time.Duration) { time.Sleep(d) },
+	)
+}
+
+func TestRedisSessionRepo(t *testing.T) {
+	var mr *miniredis.Miniredis
+	newRepo := func(t *testing.T) SessionRepository {
+		mr = miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { client.Close() })
+		return NewRedisSessionRepo(client)
+	}
+	testSessionRepository(t, newRepo, func(t *testing.T, d time.Duration) { mr.FastForward(d) })
+
+	ctx := context.Background()
+	session := func(refreshTokenID string) *models.Session {
+		return &models.Session{ID: "session-1", UserID: "user-1", RefreshTokenID: refreshTokenID}
+	}
+
+	t.Run("rotasi bersamaan dari token yang sama hanya berhasil sekali", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.Create(ctx, session("token-1"), time.Hour))
+
+		const attempts = 10
+		errs := make(chan error, attempts)
+		var wg sync.WaitGroup
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs <- repo.Rotate(ctx, session(fmt.Sprintf("token-new-%d", i)), "token-1", time.Hour)
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+			} else {
+				assert.ErrorIs(t, err, ErrSessionNotFound)
+			}
+		}
+		assert.Equal(t, 1, succeeded)
+
+		current, err := repo.FindByID(ctx, "session-1")
+		require.NoError(t, err)
+		for i := 0; i < attempts; i++ {
+			tokenID := fmt.Sprintf("token-new-%d", i)
+			_, err := repo.FindByRefreshTokenID(ctx, tokenID)
+			if tokenID == current.RefreshTokenID {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrSessionNotFound, "token yang kalah tidak boleh tersisa")
+			}
+		}
+		_, err = repo.FindByRotatedTokenID(ctx, "token-1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("rotasi tetap selesai walau context sudah dibatalkan", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.Create(ctx, session("token-1"), time.Hour))
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		require.NoError(t, repo.Rotate(cancelled, session("token-2"), "token-1", time.Hour))
+
+		current, err := repo.FindByRefreshTokenID(ctx, "token-2")
+		require.NoError(t, err)
+		assert.Equal(t, "token-2", current.RefreshTokenID)
+		_, err = repo.FindByRotatedTokenID(ctx, "token-1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("token baru dan penanda rotasi sudah tersimpan sebelum token lama diambil", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.Create(ctx, session("token-1"), time.Hour))
+
+		// A session pointing at another ID makes GETDEL's comparison fail
+		// only after the new token and marker were written.
+		require.NoError(t, mr.Set(refreshTokenKey("token-1"), "session-lain"))
+		err := repo.Rotate(ctx, session("token-2"), "token-1", time.Hour)
+
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+		assert.False(t, mr.Exists(refreshTokenKey("token-2")), "token baru dibersihkan saat gagal")
+		assert.True(t, mr.Exists(rotatedTokenKey("token-1")))
+	})
+	t.Run("sesi yang dihapus saat rotasi berjalan tidak hidup kembali", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { client.Close() })
+		repo := NewRedisSessionRepo(client)
+		require.NoError(t, repo.Create(ctx, session("token-1"), time.Hour))
+
+		// A logout that lands right after the old token was taken.
+		client.AddHook(afterCommandHook{name: "getdel", fn: func() {
+			require.NoError(t, repo.Delete(ctx, session("token-1")))
+		}})
+		err := repo.Rotate(ctx, session("token-2"), "token-1", time.Hour)
+
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+		_, err = repo.FindByID(ctx, "session-1")
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+		_, err = repo.FindByRefreshTokenID(ctx, "token-2")
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+		assert.False(t, mr.Exists(refreshTokenKey("token-2")))
+	})
+}
+
+// afterCommandHook runs fn once right after the first command called name.
+type afterCommandHook struct {
+	name string
+	fn   func()
+}
+
+func (h afterCommandHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h afterCommandHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	var once sync.Once
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		if cmd.Name() == h.name {
+			once.Do(h.fn)
+		}
+		return err
+	}
+}
+
+func (h afterCommandHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+)
+
+type TagRepository interface {
+	FindAllWithCounts(ctx context.Context) ([]models.Tag, error)
+}
+
+type pgxTagRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewPgxTagRepo(pool *pgxpool.Pool) TagRepository {
+	return &pgxTagRepo{pool: pool}
+}
+
+func (r *pgxTagRepo) FindAllWithCounts(ctx context.Context) ([]models.Tag, error) {
+	query := `
+		SELECT t.name, COUNT(a.id)
+		FROM tags t
+		LEFT JOIN article_tags atg ON atg.tag_id = t.id
+		LEFT JOIN articles a ON a.id = atg.article_id AND a.status = $1
+		GROUP BY t.name
+		HAVING COUNT(a.id) > 0
+		ORDER BY COUNT(a.id) DESC, t.name`
+
+	rows, err := r.pool.Query(ctx, query, models.ArticleStatusPublished)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]models.Tag, 0)
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.Name, &tag.ArticleCount); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
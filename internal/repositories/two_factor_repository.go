@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+)
+
+var ErrTwoFactorNotFound = errors.New("two-factor authentication is not set up")
+
+type TwoFactorRepository interface {
+	FindByUserID(ctx context.Context, userID string) (*models.TwoFactor, error)
+	SavePending(ctx context.Context, userID string, secret string) error
+	Enable(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) error
+	UseStep(ctx context.Context, userID string, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID string, codeHash string) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error
+	Delete(ctx context.Context, userID string) error
+}
+
+type pgxTwoFactorRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewPgxTwoFactorRepo(pool *pgxpool.Pool) TwoFactorRepository {
+	return &pgxTwoFactorRepo{pool: pool}
+}
+
+func (r *pgxTwoFactorRepo) FindByUserID(ctx context.Context, userID string) (*models.TwoFactor, error) {
+	query := `SELECT user_id, secret, enabled, last_used_step FROM user_totp WHERE user_id = $1`
+
+	var tf models.TwoFactor
+	err := r.pool.QueryRow(ctx, query, userID).Scan(&tf.UserID, &tf.Secret, &tf.Enabled, &tf.LastUsedStep)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTwoFactorNotFound
+		}
+		return nil, err
+	}
+	return &tf, nil
+}
+
+// SavePending stores a new secret awaiting confirmation. An enabled secret is
+// never overwritten; it has to be disabled first.
+func (r *pgxTwoFactorRepo) SavePending(ctx context.Context, userID string, secret string) error {
+	query := `
+		INSERT INTO user_totp (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, created_at = NOW()
+		WHERE user_totp.enabled = FALSE`
+	_, err := r.pool.Exec(ctx, query, userID, secret)
+	return err
+}
+
+func (r *pgxTwoFactorRepo) Enable(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmdTag, err := tx.Exec(ctx, `UPDATE user_totp SET enabled = TRUE, last_used_step = $2 WHERE user_id = $1 AND enabled = FALSE`, userID, step)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrTwoFactorNotFound
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// UseStep records step as consumed and reports false when it, or a later
+// step, was already used, so a one-time password cannot be replayed.
+func (r *pgxTwoFactorRepo) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	query := `UPDATE user_totp SET last_used_step = $2 WHERE user_id = $1 AND enabled = TRUE AND last_used_step < $2`
+	cmdTag, err := r.pool.Exec(ctx, query, userID, step)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+func (r *pgxTwoFactorRepo) UseRecoveryCode(ctx context.Context, userID string, codeHash string) (bool, error) {
+	query := `DELETE FROM user_recovery_codes WHERE user_id = $1 AND code_hash = $2`
+	cmdTag, err := r.pool.Exec(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+func (r *pgxTwoFactorRepo) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *pgxTwoFactorRepo) Delete(ctx context.Context, userID string) error {
+	cmdTag, err := r.pool.Exec(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrTwoFactorNotFound
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID string, codeHashes []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	query := `INSERT INTO user_recovery_codes (user_id, code_hash) SELECT $1, UNNEST($2::text[])`
+	_, err := tx.Exec(ctx, query, userID, codeHashes)
+	return err
+}
@@ -16,9 +16,12 @@ type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	FindByID(ctx context.Context, id string) (*models.User, error)
 	FindByUsername(ctx context.Context, username string) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
 	FindAll(ctx context.Context) ([]models.User, error)
 	Update(ctx context.Context, user *models.User) error
 	Delete(ctx context.Context, id string) error
+	UpdateRole(ctx context.Context, id string, role string) error
+	MarkEmailVerified(ctx context.Context, id string, email string) error
 }
 
 type pgxUserRepo struct {
@@ -30,18 +33,18 @@ func NewPgxUserRepo(pool *pgxpool.Pool) UserRepository {
 }
 
 func (r *pgxUserRepo) Create(ctx context.Context, user *models.User) error {
-	query := `INSERT INTO users (username, name, hashed_password) VALUES ($1, $2, $3) RETURNING id, created_at, updated_at`
-	row := r.pool.QueryRow(ctx, query, user.Username, user.Name, user.HashedPassword)
+	query := `INSERT INTO users (username, name, email, role, hashed_password) VALUES ($1, $2, NULLIF($3, ''), $4, $5) RETURNING id, created_at, updated_at`
+	row := r.pool.QueryRow(ctx, query, user.Username, user.Name, user.Email, user.Role, user.HashedPassword)
 	err := row.Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 	return err
 }
 
 func (r *pgxUserRepo) FindByID(ctx context.Context, id string) (*models.User, error) {
-	query := `SELECT id, username, name, hashed_password, created_at, updated_at FROM users WHERE id = $1`
+	query := `SELECT id, username, name, COALESCE(email, '') AS email, email_verified_at, role, hashed_password, created_at, updated_at FROM users WHERE id = $1`
 	row := r.pool.QueryRow(ctx, query, id)
 
 	var user models.User
-	err := row.Scan(&user.ID, &user.Username, &user.Name, &user.HashedPassword, &user.CreatedAt, &user.UpdatedAt)
+	err := row.Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.EmailVerifiedAt, &user.Role, &user.HashedPassword, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrUserNotFound
@@ -52,11 +55,26 @@ func (r *pgxUserRepo) FindByID(ctx context.Context, id string) (*models.User, er
 }
 
 func (r *pgxUserRepo) FindByUsername(ctx context.Context, username string) (*models.User, error) {
-	query := `SELECT id, username, name, hashed_password, created_at, updated_at FROM users WHERE username = $1`
+	query := `SELECT id, username, name, COALESCE(email, '') AS email, email_verified_at, role, hashed_password, created_at, updated_at FROM users WHERE username = $1`
 	row := r.pool.QueryRow(ctx, query, username)
 
 	var user models.User
-	err := row.Scan(&user.ID, &user.Username, &user.Name, &user.HashedPassword, &user.CreatedAt, &user.UpdatedAt)
+	err := row.Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.EmailVerifiedAt, &user.Role, &user.HashedPassword, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *pgxUserRepo) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	query := `SELECT id, username, name, COALESCE(email, '') AS email, email_verified_at, role, hashed_password, created_at, updated_at FROM users WHERE LOWER(email) = LOWER($1)`
+	row := r.pool.QueryRow(ctx, query, email)
+
+	var user models.User
+	err := row.Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.EmailVerifiedAt, &user.Role, &user.HashedPassword, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrUserNotFound
@@ -67,7 +85,7 @@ func (r *pgxUserRepo) FindByUsername(ctx context.Context, username string) (*mod
 }
 
 func (r *pgxUserRepo) FindAll(ctx context.Context) ([]models.User, error) {
-	query := `SELECT id, username, name, hashed_password, created_at, updated_at FROM users ORDER BY created_at DESC`
+	query := `SELECT id, username, name, COALESCE(email, '') AS email, email_verified_at, role, hashed_password, created_at, updated_at FROM users ORDER BY created_at DESC`
 	rows, err := r.pool.Query(ctx, query)
 	if err != nil {
 		return nil, err
@@ -83,12 +101,38 @@ func (r *pgxUserRepo) FindAll(ctx context.Context) ([]models.User, error) {
 }
 
 func (r *pgxUserRepo) Update(ctx context.Context, user *models.User) error {
-	query := `UPDATE users SET username = $1, name = $2, hashed_password = $3 WHERE id = $4 RETURNING updated_at`
-	row := r.pool.QueryRow(ctx, query, user.Username, user.Name, user.HashedPassword, user.ID)
+	query := `UPDATE users SET username = $1, name = $2, email = NULLIF($3, ''), email_verified_at = $4, hashed_password = $5 WHERE id = $6 RETURNING updated_at`
+	row := r.pool.QueryRow(ctx, query, user.Username, user.Name, user.Email, user.EmailVerifiedAt, user.HashedPassword, user.ID)
 	err := row.Scan(&user.UpdatedAt)
 	return err
 }
 
+func (r *pgxUserRepo) UpdateRole(ctx context.Context, id string, role string) error {
+	query := `UPDATE users SET role = $1 WHERE id = $2`
+	cmdTag, err := r.pool.Exec(ctx, query, role, id)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// MarkEmailVerified only succeeds while the user still has the given address,
+// so a token sent for an address that has since been replaced does nothing.
+func (r *pgxUserRepo) MarkEmailVerified(ctx context.Context, id string, email string) error {
+	query := `UPDATE users SET email_verified_at = NOW() WHERE id = $1 AND LOWER(email) = LOWER($2)`
+	cmdTag, err := r.pool.Exec(ctx, query, id, email)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
 func (r *pgxUserRepo) Delete(ctx context.Context, id string) error {
 	query := `DELETE FROM users WHERE id = $1`
 	cmdTag, err := r.pool.Exec(ctx, query, id)
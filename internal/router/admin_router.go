@@ -0,0 +1,26 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/handlers"
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/middleware"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/revocation"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/signing"
+	"github.com/gorilla/mux"
+)
+
+const userIDPath = "{id:[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}}"
+
+func RegisterAdminRoutes(r *mux.Router, userHandler *handlers.UserHandler, authHandler *handlers.AuthHandler, verifier signing.Verifier, revocations revocation.Store) {
+	adminRouter := r.PathPrefix("/admin").Subrouter()
+	adminRouter.Use(func(next http.Handler) http.Handler {
+		return middleware.JWT(next, verifier, revocations)
+	})
+	adminRouter.Use(middleware.RequireRole(models.RoleAdmin))
+	adminRouter.Use(middleware.RequireScope(models.ScopeUsersWrite))
+
+	adminRouter.HandleFunc("/users/"+userIDPath+"/role", userHandler.UpdateUserRole).Methods(http.MethodPut)
+	adminRouter.HandleFunc("/users/"+userIDPath+"/lockout", authHandler.UnlockUser).Methods(http.MethodDelete)
+}
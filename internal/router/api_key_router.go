@@ -0,0 +1,28 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/handlers"
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/middleware"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/revocation"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/signing"
+	"github.com/gorilla/mux"
+)
+
+const apiKeyIDPath = "/{id:[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}}"
+
+// RegisterAPIKeyRoutes only accepts bearer tokens: an API key cannot be used
+// to mint further keys. Managing keys is part of managing the account, so it
+// needs the users:write scope.
+func RegisterAPIKeyRoutes(r *mux.Router, h *handlers.APIKeyHandler, verifier signing.Verifier, revocations revocation.Store) {
+	apiKeyRouter := r.PathPrefix("/users/me/api-keys").Subrouter()
+	apiKeyRouter.Use(func(next http.Handler) http.Handler {
+		return middleware.JWT(next, verifier, revocations)
+	})
+	apiKeyRouter.Use(middleware.RequireScope(models.ScopeUsersWrite))
+	apiKeyRouter.HandleFunc("", h.CreateAPIKey).Methods(http.MethodPost)
+	apiKeyRouter.HandleFunc("", h.GetAPIKeys).Methods(http.MethodGet)
+	apiKeyRouter.HandleFunc(apiKeyIDPath, h.DeleteAPIKey).Methods(http.MethodDelete)
+}
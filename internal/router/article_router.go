@@ -4,21 +4,38 @@ import (
 	"net/http"
 
 	"github.com/dhifanrazaqa/kumparan-article/internal/handlers"
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
 	"github.com/dhifanrazaqa/kumparan-article/pkg/middleware"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/revocation"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/signing"
 	"github.com/gorilla/mux"
 )
 
-func RegisterArticleRoutes(r *mux.Router, h *handlers.ArticleHandler, jwtSecret string) {
+const articleIDPath = "/{id:[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}}"
+
+func RegisterArticleRoutes(r *mux.Router, h *handlers.ArticleHandler, verifier signing.Verifier, revocations revocation.Store, apiKeys middleware.APIKeyAuthenticator) {
 	articleRouter := r.PathPrefix("/articles").Subrouter()
 
-	articleRouter.HandleFunc("", h.GetArticles).Methods(http.MethodGet)
-	articleRouter.HandleFunc("/{id:[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}}", h.GetArticleByID).Methods(http.MethodGet)
+	public := articleRouter.PathPrefix("").Subrouter()
+	public.Use(func(next http.Handler) http.Handler {
+		return middleware.OptionalJWT(next, verifier, revocations)
+	})
+	public.HandleFunc("", h.GetArticles).Methods(http.MethodGet)
+	public.HandleFunc(articleIDPath, h.GetArticleByID).Methods(http.MethodGet)
+	public.HandleFunc("/by-slug/{slug:[a-z0-9-]+}", h.GetArticleBySlug).Methods(http.MethodGet)
 
 	authed := articleRouter.PathPrefix("").Subrouter()
 	authed.Use(func(next http.Handler) http.Handler {
-		return middleware.JWT(next, jwtSecret)
+		return middleware.Authenticate(next, verifier, revocations, apiKeys)
 	})
-	authed.HandleFunc("", h.CreateArticle).Methods(http.MethodPost)
-	authed.HandleFunc("/{id:[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}}", h.UpdateArticle).Methods(http.MethodPut)
-	authed.HandleFunc("/{id:[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}}", h.DeleteArticle).Methods(http.MethodDelete)
+	authed.Handle("", scoped(models.ScopeArticlesWrite, h.CreateArticle)).Methods(http.MethodPost)
+	authed.Handle(articleIDPath, scoped(models.ScopeArticlesWrite, h.UpdateArticle)).Methods(http.MethodPut)
+	authed.Handle(articleIDPath, scoped(models.ScopeArticlesWrite, h.DeleteArticle)).Methods(http.MethodDelete)
+	authed.Handle(articleIDPath+"/publish", scoped(models.ScopeArticlesWrite, h.PublishArticle)).Methods(http.MethodPost)
+	authed.Handle(articleIDPath+"/unpublish", scoped(models.ScopeArticlesWrite, h.UnpublishArticle)).Methods(http.MethodPost)
+	authed.Handle(articleIDPath+"/archive", scoped(models.ScopeArticlesWrite, h.ArchiveArticle)).Methods(http.MethodPost)
+	authed.HandleFunc(articleIDPath+"/revisions", h.GetRevisions).Methods(http.MethodGet)
+	authed.HandleFunc(articleIDPath+"/revisions/diff", h.DiffRevisions).Methods(http.MethodGet)
+	authed.HandleFunc(articleIDPath+"/revisions/{rev:[0-9]+}", h.GetRevision).Methods(http.MethodGet)
+	authed.Handle(articleIDPath+"/revisions/{rev:[0-9]+}/restore", scoped(models.ScopeArticlesWrite, h.RestoreRevision)).Methods(http.MethodPost)
 }
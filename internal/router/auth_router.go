@@ -4,11 +4,26 @@ import (
 	"net/http"
 
 	"github.com/dhifanrazaqa/kumparan-article/internal/handlers"
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/middleware"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/revocation"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/signing"
 	"github.com/gorilla/mux"
 )
 
-func RegisterAuthRoutes(r *mux.Router, h *handlers.AuthHandler) {
+func RegisterAuthRoutes(r *mux.Router, h *handlers.AuthHandler, verifier signing.Verifier, revocations revocation.Store) {
 	authRouter := r.PathPrefix("/auth").Subrouter()
 	authRouter.HandleFunc("/login", h.Login).Methods(http.MethodPost)
+	authRouter.HandleFunc("/login/2fa", h.LoginTwoFactor).Methods(http.MethodPost)
 	authRouter.HandleFunc("/refresh", h.RefreshToken).Methods(http.MethodPost)
+	authRouter.HandleFunc("/logout", h.Logout).Methods(http.MethodPost)
+
+	authed := authRouter.PathPrefix("").Subrouter()
+	authed.Use(func(next http.Handler) http.Handler {
+		return middleware.JWT(next, verifier, revocations)
+	})
+	authed.Use(middleware.RequireScope(models.ScopeUsersWrite))
+	authed.HandleFunc("/logout-all", h.LogoutAll).Methods(http.MethodPost)
+	authed.HandleFunc("/sessions", h.GetSessions).Methods(http.MethodGet)
+	authed.HandleFunc("/sessions/{id:[0-9a-f]{32}}", h.RevokeSession).Methods(http.MethodDelete)
 }
@@ -0,0 +1,32 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/handlers"
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/middleware"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/revocation"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/signing"
+	"github.com/gorilla/mux"
+)
+
+const commentIDPath = "/{commentId:[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}}"
+
+func RegisterCommentRoutes(r *mux.Router, h *handlers.CommentHandler, verifier signing.Verifier, revocations revocation.Store, apiKeys middleware.APIKeyAuthenticator) {
+	commentRouter := r.PathPrefix("/articles" + articleIDPath + "/comments").Subrouter()
+
+	public := commentRouter.PathPrefix("").Subrouter()
+	public.Use(func(next http.Handler) http.Handler {
+		return middleware.OptionalJWT(next, verifier, revocations)
+	})
+	public.HandleFunc("", h.GetComments).Methods(http.MethodGet)
+
+	authed := commentRouter.PathPrefix("").Subrouter()
+	authed.Use(func(next http.Handler) http.Handler {
+		return middleware.Authenticate(next, verifier, revocations, apiKeys)
+	})
+	authed.Handle("", scoped(models.ScopeCommentsWrite, h.CreateComment)).Methods(http.MethodPost)
+	authed.Handle(commentIDPath, scoped(models.ScopeCommentsWrite, h.UpdateComment)).Methods(http.MethodPut)
+	authed.Handle(commentIDPath, scoped(models.ScopeCommentsWrite, h.DeleteComment)).Methods(http.MethodDelete)
+}
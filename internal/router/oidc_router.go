@@ -0,0 +1,14 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/handlers"
+	"github.com/gorilla/mux"
+)
+
+func RegisterOIDCRoutes(r *mux.Router, h *handlers.OIDCHandler) {
+	oidcRouter := r.PathPrefix("/auth/oidc/{provider}").Subrouter()
+	oidcRouter.HandleFunc("/start", h.Start).Methods(http.MethodGet)
+	oidcRouter.HandleFunc("/callback", h.Callback).Methods(http.MethodGet)
+}
@@ -0,0 +1,14 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/handlers"
+	"github.com/gorilla/mux"
+)
+
+func RegisterPasswordResetRoutes(r *mux.Router, h *handlers.PasswordResetHandler) {
+	passwordRouter := r.PathPrefix("/auth/password").Subrouter()
+	passwordRouter.HandleFunc("/forgot", h.ForgotPassword).Methods(http.MethodPost)
+	passwordRouter.HandleFunc("/reset", h.ResetPassword).Methods(http.MethodPost)
+}
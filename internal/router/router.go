@@ -1,23 +1,55 @@
 package router
 
 import (
+	"net"
+	"net/http"
+
 	"github.com/dhifanrazaqa/kumparan-article/internal/handlers"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/middleware"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/revocation"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/signing"
 	"github.com/gorilla/mux"
 )
 
 type Deps struct {
-	AuthHandler    *handlers.AuthHandler
-	UserHandler    *handlers.UserHandler
-	ArticleHandler *handlers.ArticleHandler
-	JWTSecret      string
+	AuthHandler      *handlers.AuthHandler
+	UserHandler      *handlers.UserHandler
+	ArticleHandler   *handlers.ArticleHandler
+	TagHandler       *handlers.TagHandler
+	CommentHandler   *handlers.CommentHandler
+	JWKSHandler      *handlers.JWKSHandler
+	TwoFactorHandler *handlers.TwoFactorHandler
+	PasswordHandler  *handlers.PasswordResetHandler
+	OIDCHandler      *handlers.OIDCHandler
+	APIKeyHandler    *handlers.APIKeyHandler
+	TokenVerifier    signing.Verifier
+	Revocations      revocation.Store
+	APIKeys          middleware.APIKeyAuthenticator
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For header is
+	// used to find the client address.
+	TrustedProxies []*net.IPNet
+}
+
+// scoped declares the scope a route needs on top of authentication.
+func scoped(scope string, h http.HandlerFunc) http.Handler {
+	return middleware.RequireScope(scope)(h)
 }
 
 func SetupRouter(d Deps) *mux.Router {
 	router := mux.NewRouter()
+	router.Use(middleware.RealIP(d.TrustedProxies))
 
-	RegisterAuthRoutes(router, d.AuthHandler)
-	RegisterUserRoutes(router, d.UserHandler, d.JWTSecret)
-	RegisterArticleRoutes(router, d.ArticleHandler, d.JWTSecret)
+	RegisterAuthRoutes(router, d.AuthHandler, d.TokenVerifier, d.Revocations)
+	RegisterTwoFactorRoutes(router, d.TwoFactorHandler, d.TokenVerifier, d.Revocations)
+	RegisterPasswordResetRoutes(router, d.PasswordHandler)
+	RegisterOIDCRoutes(router, d.OIDCHandler)
+	RegisterAPIKeyRoutes(router, d.APIKeyHandler, d.TokenVerifier, d.Revocations)
+	RegisterUserRoutes(router, d.UserHandler, d.TokenVerifier, d.Revocations, d.APIKeys)
+	RegisterArticleRoutes(router, d.ArticleHandler, d.TokenVerifier, d.Revocations, d.APIKeys)
+	RegisterTagRoutes(router, d.TagHandler)
+	RegisterCommentRoutes(router, d.CommentHandler, d.TokenVerifier, d.Revocations, d.APIKeys)
+	RegisterAdminRoutes(router, d.UserHandler, d.AuthHandler, d.TokenVerifier, d.Revocations)
+	RegisterWellKnownRoutes(router, d.JWKSHandler)
 
 	return router
 }
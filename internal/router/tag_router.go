@@ -0,0 +1,13 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/handlers"
+	"github.com/gorilla/mux"
+)
+
+func RegisterTagRoutes(r *mux.Router, h *handlers.TagHandler) {
+	tagRouter := r.PathPrefix("/tags").Subrouter()
+	tagRouter.HandleFunc("", h.GetTags).Methods(http.MethodGet)
+}
@@ -0,0 +1,24 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/handlers"
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/middleware"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/revocation"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/signing"
+	"github.com/gorilla/mux"
+)
+
+func RegisterTwoFactorRoutes(r *mux.Router, h *handlers.TwoFactorHandler, verifier signing.Verifier, revocations revocation.Store) {
+	twoFactorRouter := r.PathPrefix("/auth/2fa").Subrouter()
+	twoFactorRouter.Use(func(next http.Handler) http.Handler {
+		return middleware.JWT(next, verifier, revocations)
+	})
+	twoFactorRouter.Use(middleware.RequireScope(models.ScopeUsersWrite))
+	twoFactorRouter.HandleFunc("/enroll", h.Enroll).Methods(http.MethodPost)
+	twoFactorRouter.HandleFunc("/enable", h.Enable).Methods(http.MethodPost)
+	twoFactorRouter.HandleFunc("/disable", h.Disable).Methods(http.MethodPost)
+	twoFactorRouter.HandleFunc("/recovery-codes", h.RegenerateRecoveryCodes).Methods(http.MethodPost)
+}
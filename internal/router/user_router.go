@@ -4,21 +4,29 @@ import (
 	"net/http"
 
 	"github.com/dhifanrazaqa/kumparan-article/internal/handlers"
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
 	"github.com/dhifanrazaqa/kumparan-article/pkg/middleware"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/revocation"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/signing"
 	"github.com/gorilla/mux"
 )
 
-func RegisterUserRoutes(router *mux.Router, h *handlers.UserHandler, jwtSecret string) {
+func RegisterUserRoutes(router *mux.Router, h *handlers.UserHandler, verifier signing.Verifier, revocations revocation.Store, apiKeys middleware.APIKeyAuthenticator) {
 	userRouter := router.PathPrefix("/users").Subrouter()
 
 	userRouter.HandleFunc("", h.CreateUser).Methods(http.MethodPost)
 	userRouter.HandleFunc("", h.GetUsers).Methods(http.MethodGet)
+	userRouter.HandleFunc("/verify-email", h.VerifyEmail).Methods(http.MethodPost)
 	userRouter.HandleFunc("/{id:[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}}", h.GetUserByID).Methods(http.MethodGet)
 
 	authed := userRouter.PathPrefix("").Subrouter()
 	authed.Use(func(next http.Handler) http.Handler {
-		return middleware.JWT(next, jwtSecret)
+		return middleware.Authenticate(next, verifier, revocations, apiKeys)
 	})
-	authed.HandleFunc("/{id:[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}}", h.UpdateUser).Methods(http.MethodPut)
-	authed.HandleFunc("/{id:[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}}", h.DeleteUser).Methods(http.MethodDelete)
+	authed.HandleFunc("/me", h.GetMe).Methods(http.MethodGet)
+	authed.Handle("/me", scoped(models.ScopeUsersWrite, h.UpdateMe)).Methods(http.MethodPatch)
+	authed.Handle("/me", scoped(models.ScopeUsersWrite, h.DeleteMe)).Methods(http.MethodDelete)
+	authed.Handle("/me/password", scoped(models.ScopeUsersWrite, h.ChangePassword)).Methods(http.MethodPut)
+	authed.Handle("/{id:[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}}", scoped(models.ScopeUsersWrite, h.UpdateUser)).Methods(http.MethodPut)
+	authed.Handle("/{id:[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}}", scoped(models.ScopeUsersWrite, h.DeleteUser)).Methods(http.MethodDelete)
 }
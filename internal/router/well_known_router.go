@@ -0,0 +1,13 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/handlers"
+	"github.com/gorilla/mux"
+)
+
+func RegisterWellKnownRoutes(r *mux.Router, h *handlers.JWKSHandler) {
+	wellKnownRouter := r.PathPrefix("/.well-known").Subrouter()
+	wellKnownRouter.HandleFunc("/jwks.json", h.GetJWKS).Methods(http.MethodGet)
+}
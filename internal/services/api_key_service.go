@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/policy"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/apikey"
+)
+
+var (
+	ErrInvalidAPIKeyName   = errors.New("API key name must be between 1 and 100 characters")
+	ErrInvalidScope        = errors.New("unknown scope")
+	ErrScopeNotGranted     = errors.New("cannot grant a scope the current token does not have")
+	ErrInvalidAPIKeyExpiry = errors.New("API key expiry must be in the future")
+)
+
+const maxAPIKeyNameLength = 100
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, userID string, req models.CreateAPIKeyRequest, granted []string) (*models.CreatedAPIKey, error)
+	GetAPIKeys(ctx context.Context, userID string) ([]models.APIKey, error)
+	DeleteAPIKey(ctx context.Context, userID string, id string) error
+	AuthenticateAPIKey(ctx context.Context, key string) (*models.Claims, error)
+}
+
+type apiKeyService struct {
+	repo repositories.APIKeyRepository
+}
+
+func NewAPIKeyService(repo repositories.APIKeyRepository) APIKeyService {
+	return &apiKeyService{repo: repo}
+}
+
+// CreateAPIKey creates a key with at most the granted scopes of the token
+// that asks for it, and all of them when the scopes field is omitted. An empty
+// list creates a read-only key.
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, userID string, req models.CreateAPIKeyRequest, granted []string) (*models.CreatedAPIKey, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxAPIKeyNameLength {
+		return nil, ErrInvalidAPIKeyName
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidAPIKeyExpiry
+	}
+	if req.Scopes == nil {
+		req.Scopes = granted
+	}
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(granted, scope) {
+			return nil, ErrScopeNotGranted
+		}
+	}
+
+	key, prefix, hash := apikey.Generate()
+	apiKey := &models.APIKey{
+		UserID:       userID,
+		Name:         name,
+		Prefix:       prefix,
+		HashedSecret: hash,
+		Scopes:       scopes,
+		ExpiresAt:    req.ExpiresAt,
+	}
+	if err := s.repo.Create(ctx, apiKey); err != nil {
+		return nil, err
+	}
+	return &models.CreatedAPIKey{APIKey: *apiKey, Key: key}, nil
+}
+
+func (s *apiKeyService) GetAPIKeys(ctx context.Context, userID string) ([]models.APIKey, error) {
+	return s.repo.FindByUserID(ctx, userID)
+}
+
+func (s *apiKeyService) DeleteAPIKey(ctx context.Context, userID string, id string) error {
+	return s.repo.Delete(ctx, id, userID)
+}
+
+// AuthenticateAPIKey resolves a key to the claims of its owner. The owner's
+// role is read on every use, so role changes apply to existing keys.
+func (s *apiKeyService) AuthenticateAPIKey(ctx context.Context, key string) (*models.Claims, error) {
+	prefix, secret, err := apikey.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, owner, err := s.repo.FindByPrefix(ctx, prefix)
+	if errors.Is(err, repositories.ErrAPIKeyNotFound) {
+		return nil, apikey.ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !apikey.Verify(secret, apiKey.HashedSecret) {
+		return nil, apikey.ErrInvalidKey
+	}
+	if apiKey.ExpiresAt != nil && time.Now().After(*apiKey.ExpiresAt) {
+		return nil, apikey.ErrInvalidKey
+	}
+
+	if err := s.repo.TouchLastUsed(ctx, apiKey.ID); err != nil {
+		log.Printf("Failed to record API key use: %v", err)
+	}
+
+	return &models.Claims{
+		UserID:       owner.ID,
+		Username:     owner.Username,
+		Role:         owner.Role,
+		Scope:        strings.Join(apiKey.Scopes, " "),
+		ScopeVersion: models.ScopeClaimVersion,
+	}, nil
+}
+
+// normalizeScopes validates and de-duplicates requested scopes. No scopes
+// means no write access; callers that default to every scope must do so
+// before calling it.
+func normalizeScopes(requested []string) ([]string, error) {
+	seen := make(map[string]bool, len(requested))
+	scopes := make([]string, 0, len(requested))
+	for _, scope := range requested {
+		if !policy.IsValidScope(scope) {
+			return nil, ErrInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/apikey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAPIKeyRepo struct {
+	mock.Mock
+	repositories.APIKeyRepository
+}
+
+func (m *MockAPIKeyRepo) Create(ctx context.Context, key *models.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepo) FindByPrefix(ctx context.Context, prefix string) (*models.APIKey, *models.User, error) {
+	args := m.Called(ctx, prefix)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.APIKey), args.Get(1).(*models.User), args.Error(2)
+}
+
+func (m *MockAPIKeyRepo) TouchLastUsed(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func TestAPIKeyService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("kunci baru disimpan sebagai hash dengan semua scope", func(t *testing.T) {
+		repo := new(MockAPIKeyRepo)
+		service := NewAPIKeyService(repo)
+		repo.On("Create", ctx, mock.AnythingOfType("*models.APIKey")).Return(nil).Once()
+
+		created, err := service.CreateAPIKey(ctx, "user-1", models.CreateAPIKeyRequest{Name: "ci"}, models.AllScopes)
+		require.NoError(t, err)
+		assert.Equal(t, models.AllScopes, created.Scopes)
+
+		prefix, secret, err := apikey.Parse(created.Key)
+		require.NoError(t, err)
+		assert.Equal(t, prefix, created.Prefix)
+		assert.True(t, apikey.Verify(secret, created.HashedSecret))
+	})
+
+	t.Run("daftar scope kosong menghasilkan kunci hanya-baca", func(t *testing.T) {
+		repo := new(MockAPIKeyRepo)
+		service := NewAPIKeyService(repo)
+		repo.On("Create", ctx, mock.AnythingOfType("*models.APIKey")).Return(nil).Once()
+
+		created, err := service.CreateAPIKey(ctx, "user-1", models.CreateAPIKeyRequest{Name: "ci", Scopes: []string{}}, models.AllScopes)
+		require.NoError(t, err)
+		assert.Empty(t, created.Scopes)
+
+		repo.On("FindByPrefix", ctx, created.Prefix).Return(&created.APIKey, &models.User{ID: "user-1"}, nil).Once()
+		repo.On("TouchLastUsed", ctx, created.ID).Return(nil).Once()
+		claims, err := service.AuthenticateAPIKey(ctx, created.Key)
+		require.NoError(t, err)
+		assert.Empty(t, claims.Scopes())
+	})
+
+	t.Run("scope yang tidak dikenal ditolak", func(t *testing.T) {
+		service := NewAPIKeyService(new(MockAPIKeyRepo))
+		_, err := service.CreateAPIKey(ctx, "user-1", models.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"admin:all"}}, models.AllScopes)
+		assert.ErrorIs(t, err, ErrInvalidScope)
+	})
+
+	t.Run("kunci tidak bisa melebihi scope token pembuatnya", func(t *testing.T) {
+		service := NewAPIKeyService(new(MockAPIKeyRepo))
+		granted := []string{models.ScopeArticlesWrite}
+		_, err := service.CreateAPIKey(ctx, "user-1", models.CreateAPIKeyRequest{Name: "ci", Scopes: []string{models.ScopeUsersWrite}}, granted)
+		assert.ErrorIs(t, err, ErrScopeNotGranted)
+	})
+
+	t.Run("kunci valid menghasilkan claims pemilik", func(t *testing.T) {
+		repo := new(MockAPIKeyRepo)
+		service := NewAPIKeyService(repo)
+
+		key, prefix, hash := apikey.Generate()
+		repo.On("FindByPrefix", ctx, prefix).Return(&models.APIKey{ID: "key-1", UserID: "user-1", HashedSecret: hash}, &models.User{ID: "user-1", Username: "budi", Role: models.RoleEditor}, nil).Once()
+		repo.On("TouchLastUsed", ctx, "key-1").Return(nil).Once()
+
+		claims, err := service.AuthenticateAPIKey(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", claims.UserID)
+		assert.Equal(t, models.RoleEditor, claims.Role)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("kunci kedaluwarsa ditolak", func(t *testing.T) {
+		repo := new(MockAPIKeyRepo)
+		service := NewAPIKeyService(repo)
+
+		key, prefix, hash := apikey.Generate()
+		expired := time.Now().Add(-time.Minute)
+		repo.On("FindByPrefix", ctx, prefix).Return(&models.APIKey{ID: "key-1", HashedSecret: hash, ExpiresAt: &expired}, &models.User{}, nil).Once()
+
+		_, err := service.AuthenticateAPIKey(ctx, key)
+		assert.ErrorIs(t, err, apikey.ErrInvalidKey)
+	})
+
+	t.Run("secret yang salah ditolak", func(t *testing.T) {
+		repo := new(MockAPIKeyRepo)
+		service := NewAPIKeyService(repo)
+
+		_, prefix, _ := apikey.Generate()
+		_, _, otherHash := apikey.Generate()
+		repo.On("FindByPrefix", ctx, prefix).Return(&models.APIKey{ID: "key-1", HashedSecret: otherHash}, &models.User{}, nil).Once()
+
+		_, err := service.AuthenticateAPIKey(ctx, "ak_"+prefix+"_tebakan")
+		assert.ErrorIs(t, err, apikey.ErrInvalidKey)
+	})
+}
@@ -2,47 +2,177 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/policy"
 	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
-	"github.com/go-redis/redis"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/cache"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	articleCacheTTL          = 5 * time.Minute
+	articleListGenerationKey = "articles:list:generation"
+
+	// List pages embed author names, which change without bumping the list
+	// generation, so they are kept for less time than single articles.
+	articleListCacheTTL = time.Minute
+
+	// maxTagLength matches the tags.name column.
+	maxTagLength = 50
+
+	// maxSlugAttempts bounds how often a write is retried when concurrent
+	// writes keep claiming the slug it picked.
+	maxSlugAttempts = 5
+)
+
+var (
+	ErrInvalidArticleStatus    = errors.New("invalid article status")
+	ErrInvalidStatusTransition = errors.New("article cannot be moved to the requested status")
+	ErrEmailNotVerified        = errors.New("verify your email address before publishing articles")
+	ErrInvalidTag              = fmt.Errorf("tags must be at most %d characters long", maxTagLength)
 )
 
 type ArticleService interface {
 	CreateArticle(ctx context.Context, req models.CreateArticleRequest, authorID string) (*models.Article, error)
 	GetArticles(ctx context.Context, params models.ListArticlesParams) (*models.PaginatedArticles, error)
-	GetArticleByID(ctx context.Context, id string) (*models.Article, error)
-	UpdateArticle(ctx context.Context, id string, req models.UpdateArticleRequest, currentUserID string) (*models.Article, error)
-	DeleteArticle(ctx context.Context, id string, currentUserID string) error
+	GetArticleByID(ctx context.Context, id string, viewer models.Actor) (*models.Article, error)
+	GetArticleBySlug(ctx context.Context, slug string, viewer models.Actor) (*models.Article, error)
+	UpdateArticle(ctx context.Context, id string, req models.UpdateArticleRequest, actor models.Actor) (*models.Article, error)
+	DeleteArticle(ctx context.Context, id string, actor models.Actor) error
+	PublishArticle(ctx context.Context, id string, actor models.Actor) (*models.Article, error)
+	UnpublishArticle(ctx context.Context, id string, actor models.Actor) (*models.Article, error)
+	ArchiveArticle(ctx context.Context, id string, actor models.Actor) (*models.Article, error)
+	GetRevisions(ctx context.Context, id string, actor models.Actor) ([]models.ArticleRevision, error)
+	GetRevision(ctx context.Context, id string, revision int, actor models.Actor) (*models.ArticleRevision, error)
+	DiffRevisions(ctx context.Context, id string, from, to int, actor models.Actor) (*models.RevisionDiff, error)
+	RestoreRevision(ctx context.Context, id string, revision int, actor models.Actor) (*models.Article, error)
 }
 
 type articleService struct {
-	repo        repositories.ArticleRepository
-	redisClient *redis.Client
+	repo                 repositories.ArticleRepository
+	cache                cache.Cache
+	versions             cache.Cache
+	userRepo             repositories.UserRepository
+	requireVerifiedEmail bool
+	softTTL              time.Duration
+
+	// loads coalesces concurrent cache fills of the same article.
+	loads singleflight.Group
 }
 
-func NewArticleService(repo repositories.ArticleRepository, redisClient *redis.Client) ArticleService {
-	return &articleService{repo: repo, redisClient: redisClient}
+// NewArticleService creates the article service. When requireVerifiedEmail is
+// set, only users with a verified email address can publish; drafts are
+// always allowed. A non-zero softTTL enables stale-while-revalidate for
+// cached articles: entries older than softTTL are still served, while one
+// request refreshes them in the background.
+//
+// versions holds the list generation and per-article versions that retire
+// cached entries. It must be shared by every instance and must not evict
+// keys early, unlike articleCache which may be a local or bounded cache: a
+// lost or instance-local version would bring retired entries back.
+func NewArticleService(repo repositories.ArticleRepository, articleCache cache.Cache, versions cache.Cache, userRepo repositories.UserRepository, requireVerifiedEmail bool, softTTL time.Duration) ArticleService {
+	return &articleService{
+		repo:                 repo,
+		cache:                articleCache,
+		versions:             versions,
+		userRepo:             userRepo,
+		requireVerifiedEmail: requireVerifiedEmail,
+		softTTL:              softTTL,
+	}
 }
 
 func (s *articleService) CreateArticle(ctx context.Context, req models.CreateArticleRequest, authorID string) (*models.Article, error) {
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
 	article := &models.Article{
 		Title:    req.Title,
 		Body:     req.Body,
 		AuthorID: authorID,
+		Status:   models.ArticleStatusDraft,
+		Tags:     tags,
+	}
+
+	slug, err := s.uniqueSlug(ctx, req.Title, "")
+	if err != nil {
+		return nil, err
 	}
-	if err := s.repo.Create(ctx, article); err != nil {
+	article.Slug = slug
+
+	switch req.Status {
+	case "", models.ArticleStatusDraft:
+	case models.ArticleStatusPublished:
+		if err := s.checkCanPublish(ctx, authorID); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		article.Status = models.ArticleStatusPublished
+		article.PublishedAt = &now
+	default:
+		return nil, ErrInvalidArticleStatus
+	}
+
+	if err := s.saveWithUniqueSlug(ctx, article, func() error { return s.repo.Create(ctx, article) }); err != nil {
 		return nil, err
 	}
-	s.clearArticleCache()
+	s.invalidateArticleLists(ctx)
 	return article, nil
 }
 
+// GetArticles serves list pages from the cache when it can. The generation is
+// read before querying, so a page built while a write is in flight is stored
+// under the old generation and never served.
 func (s *articleService) GetArticles(ctx context.Context, params models.ListArticlesParams) (*models.PaginatedArticles, error) {
+	params, err := normalizeListParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	generation, err := s.versions.Get(ctx, articleListGenerationKey)
+	if errors.Is(err, cache.ErrNotFound) {
+		generation, err = []byte("0"), nil
+	}
+	if err != nil {
+		log.Printf("Failed to read article list generation, skipping cache: %v", err)
+		return s.listArticles(ctx, params)
+	}
+
+	cacheKey := articleListCacheKey(string(generation), params)
+	val, err := s.cache.Get(ctx, cacheKey)
+	if err == nil {
+		var page models.PaginatedArticles
+		if json.Unmarshal(val, &page) == nil {
+			return &page, nil
+		}
+	}
+
+	page, err := s.listArticles(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonData, _ := json.Marshal(page)
+	s.cache.Set(ctx, cacheKey, jsonData, articleListCacheTTL)
+	return page, nil
+}
+
+func (s *articleService) listArticles(ctx context.Context, params models.ListArticlesParams) (*models.PaginatedArticles, error) {
 	g, ctx := errgroup.WithContext(ctx)
 
 	var articles []models.Article
@@ -58,21 +188,21 @@ func (s *articleService) GetArticles(ctx context.Context, params models.ListArti
 		total, err = s.repo.CountAll(ctx, params)
 		return err
 	})
-	
+
 	if err := g.Wait(); err != nil {
 		return nil, err
 	}
-	
+
 	totalPages := 0
 	if total > 0 && params.Limit > 0 {
 		totalPages = int((total + int64(params.Limit) - 1) / int64(params.Limit))
 	}
-	
+
 	currentPage := 1
 	if params.Limit > 0 {
 		currentPage = (params.Offset / params.Limit) + 1
 	}
-	
+
 	return &models.PaginatedArticles{
 		Data:       articles,
 		Total:      total,
@@ -82,62 +212,60 @@ func (s *articleService) GetArticles(ctx context.Context, params models.ListArti
 	}, nil
 }
 
-func (s *articleService) GetArticleByID(ctx context.Context, id string) (*models.Article, error) {
-	cacheKey := "article:" + id
-
-	val, err := s.redisClient.Get(cacheKey).Result()
-	if err == nil {
-		var article models.Article
-		if json.Unmarshal([]byte(val), &article) == nil {
-			log.Printf("Cache HIT for article ID: %s", id)
-			return &article, nil
-		}
-	}
-
-	log.Printf("Cache MISS for article ID: %s", id)
-	article, err := s.repo.FindByID(ctx, id)
+func (s *articleService) GetArticleByID(ctx context.Context, id string, viewer models.Actor) (*models.Article, error) {
+	article, err := s.cachedArticle(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-
-	jsonData, _ := json.Marshal(article)
-	s.redisClient.Set(cacheKey, jsonData, 5*time.Minute)
-
+	if !policy.CanViewArticle(viewer, article) {
+		return nil, repositories.ErrArticleNotFound
+	}
 	return article, nil
 }
 
-func (s *articleService) UpdateArticle(ctx context.Context, id string, req models.UpdateArticleRequest, currentUserID string) (*models.Article, error) {
-	article, err := s.repo.FindByID(ctx, id)
+func (s *articleService) GetArticleBySlug(ctx context.Context, slug string, viewer models.Actor) (*models.Article, error) {
+	id, err := s.repo.FindIDBySlug(ctx, slug)
 	if err != nil {
 		return nil, err
 	}
+	return s.GetArticleByID(ctx, id, viewer)
+}
 
-	if article.AuthorID != currentUserID {
-		return nil, ErrForbidden
+func (s *articleService) UpdateArticle(ctx context.Context, id string, req models.UpdateArticleRequest, actor models.Actor) (*models.Article, error) {
+	article, err := s.findEditableArticle(ctx, id, actor)
+	if err != nil {
+		return nil, err
 	}
 
 	if req.Title != "" {
-		article.Title = req.Title
+		if err := s.setTitle(ctx, article, req.Title); err != nil {
+			return nil, err
+		}
 	}
 	if req.Body != "" {
 		article.Body = req.Body
 	}
+	if req.Tags != nil {
+		if article.Tags, err = normalizeTags(req.Tags); err != nil {
+			return nil, err
+		}
+	}
 
-	if err := s.repo.Update(ctx, article); err != nil {
+	if err := s.saveWithUniqueSlug(ctx, article, func() error { return s.repo.Update(ctx, article, actor.UserID) }); err != nil {
 		return nil, err
 	}
 
-	s.clearArticleCache()
+	s.invalidateArticle(ctx, article.ID)
 	return article, nil
 }
 
-func (s *articleService) DeleteArticle(ctx context.Context, id string, currentUserID string) error {
+func (s *articleService) DeleteArticle(ctx context.Context, id string, actor models.Actor) error {
 	article, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	if article.AuthorID != currentUserID {
+	if !policy.CanDeleteArticle(actor, article) {
 		return ErrForbidden
 	}
 
@@ -145,13 +273,356 @@ func (s *articleService) DeleteArticle(ctx context.Context, id string, currentUs
 		return err
 	}
 
-	s.clearArticleCache()
+	s.invalidateArticle(ctx, id)
+	return nil
+}
+
+func (s *articleService) PublishArticle(ctx context.Context, id string, actor models.Actor) (*models.Article, error) {
+	return s.changeStatus(ctx, id, actor, models.ArticleStatusPublished)
+}
+
+func (s *articleService) UnpublishArticle(ctx context.Context, id string, actor models.Actor) (*models.Article, error) {
+	return s.changeStatus(ctx, id, actor, models.ArticleStatusDraft)
+}
+
+func (s *articleService) ArchiveArticle(ctx context.Context, id string, actor models.Actor) (*models.Article, error) {
+	return s.changeStatus(ctx, id, actor, models.ArticleStatusArchived)
+}
+
+func (s *articleService) changeStatus(ctx context.Context, id string, actor models.Actor, status string) (*models.Article, error) {
+	article, err := s.findEditableArticle(ctx, id, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canTransition(article.Status, status) {
+		return nil, ErrInvalidStatusTransition
+	}
+	if status == models.ArticleStatusPublished {
+		if err := s.checkCanPublish(ctx, actor.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	article.Status = status
+	switch status {
+	case models.ArticleStatusPublished:
+		now := time.Now()
+		article.PublishedAt = &now
+	case models.ArticleStatusDraft:
+		article.PublishedAt = nil
+	}
+
+	if err := s.repo.UpdateStatus(ctx, article); err != nil {
+		return nil, err
+	}
+
+	s.invalidateArticle(ctx, article.ID)
+	return article, nil
+}
+
+func (s *articleService) checkCanPublish(ctx context.Context, userID string) error {
+	if !s.requireVerifiedEmail {
+		return nil
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt == nil {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+func (s *articleService) GetRevisions(ctx context.Context, id string, actor models.Actor) ([]models.ArticleRevision, error) {
+	if _, err := s.findEditableArticle(ctx, id, actor); err != nil {
+		return nil, err
+	}
+	return s.repo.FindRevisions(ctx, id)
+}
+
+func (s *articleService) GetRevision(ctx context.Context, id string, revision int, actor models.Actor) (*models.ArticleRevision, error) {
+	if _, err := s.findEditableArticle(ctx, id, actor); err != nil {
+		return nil, err
+	}
+	return s.repo.FindRevision(ctx, id, revision)
+}
+
+func (s *articleService) DiffRevisions(ctx context.Context, id string, from, to int, actor models.Actor) (*models.RevisionDiff, error) {
+	if _, err := s.findEditableArticle(ctx, id, actor); err != nil {
+		return nil, err
+	}
+
+	fromRev, err := s.repo.FindRevision(ctx, id, from)
+	if err != nil {
+		return nil, err
+	}
+	toRev, err := s.repo.FindRevision(ctx, id, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RevisionDiff{
+		From:  from,
+		To:    to,
+		Title: utils.DiffLines(fromRev.Title, toRev.Title),
+		Body:  utils.DiffLines(fromRev.Body, toRev.Body),
+	}, nil
+}
+
+func (s *articleService) RestoreRevision(ctx context.Context, id string, revision int, actor models.Actor) (*models.Article, error) {
+	article, err := s.findEditableArticle(ctx, id, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	rev, err := s.repo.FindRevision(ctx, id, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.setTitle(ctx, article, rev.Title); err != nil {
+		return nil, err
+	}
+	article.Body = rev.Body
+	if err := s.saveWithUniqueSlug(ctx, article, func() error { return s.repo.Update(ctx, article, actor.UserID) }); err != nil {
+		return nil, err
+	}
+
+	s.invalidateArticle(ctx, article.ID)
+	return article, nil
+}
+
+// setTitle changes the article title and regenerates its slug when the title
+// produces a different one. The previous slug is kept as a redirect by the repository.
+func (s *articleService) setTitle(ctx context.Context, article *models.Article, title string) error {
+	if utils.Slugify(title) != utils.Slugify(article.Title) {
+		slug, err := s.uniqueSlug(ctx, title, article.ID)
+		if err != nil {
+			return err
+		}
+		article.Slug = slug
+	}
+	article.Title = title
 	return nil
 }
 
-func (s *articleService) clearArticleCache() {
-	iter := s.redisClient.Scan(0, "article:*", 0).Iterator()
-	for iter.Next() {
-		s.redisClient.Del(iter.Val())
+func (s *articleService) uniqueSlug(ctx context.Context, title string, articleID string) (string, error) {
+	base := utils.Slugify(title)
+	slug := base
+	for i := 2; ; i++ {
+		taken, err := s.repo.SlugTaken(ctx, slug, articleID)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// saveWithUniqueSlug runs save, and when a concurrent write claimed the
+// article's slug first, moves the article to the next free slug and retries.
+func (s *articleService) saveWithUniqueSlug(ctx context.Context, article *models.Article, save func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := save()
+		if !errors.Is(err, repositories.ErrSlugTaken) || attempt == maxSlugAttempts {
+			return err
+		}
+		if article.Slug, err = s.uniqueSlug(ctx, article.Title, article.ID); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *articleService) findEditableArticle(ctx context.Context, id string, actor models.Actor) (*models.Article, error) {
+	article, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !policy.CanEditArticle(actor, article) {
+		return nil, ErrForbidden
+	}
+	return article, nil
+}
+
+func canTransition(from, to string) bool {
+	switch to {
+	case models.ArticleStatusPublished:
+		return from == models.ArticleStatusDraft || from == models.ArticleStatusArchived
+	case models.ArticleStatusDraft:
+		return from == models.ArticleStatusPublished
+	case models.ArticleStatusArchived:
+		return from == models.ArticleStatusDraft || from == models.ArticleStatusPublished
+	}
+	return false
+}
+
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, ErrInvalidTag
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized, nil
+}
+
+func articleCacheKey(id string) string {
+	return "article:" + id
+}
+
+// articleVersionKey counts the invalidations of an article, so a load that
+// raced with one can tell its result is outdated.
+func articleVersionKey(id string) string {
+	return "article_version:" + id
+}
+
+func (s *articleService) articleVersion(ctx context.Context, id string) (string, error) {
+	val, err := s.versions.Get(ctx, articleVersionKey(id))
+	if errors.Is(err, cache.ErrNotFound) {
+		return "0", nil
+	}
+	return string(val), err
+}
+
+// articleCacheEntry is stored under article:{id}. Past FreshUntil the entry
+// is stale: it is still served until the key expires, but refreshed first.
+type articleCacheEntry struct {
+	Article    *models.Article `json:"article"`
+	FreshUntil time.Time       `json:"freshUntil"`
+}
+
+// cachedArticle reads an article through the cache. Misses for the same
+// article are coalesced into a single query, and stale entries are served
+// while one background load refreshes them.
+func (s *articleService) cachedArticle(ctx context.Context, id string) (*models.Article, error) {
+	val, err := s.cache.Get(ctx, articleCacheKey(id))
+	if err == nil {
+		var entry articleCacheEntry
+		if json.Unmarshal(val, &entry) == nil && entry.Article != nil {
+			if time.Now().After(entry.FreshUntil) {
+				log.Printf("Cache STALE for article ID: %s", id)
+				s.loads.DoChan(id, func() (interface{}, error) {
+					return s.loadArticle(context.WithoutCancel(ctx), id)
+				})
+			} else {
+				log.Printf("Cache HIT for article ID: %s", id)
+			}
+			return entry.Article, nil
+		}
+	}
+
+	log.Printf("Cache MISS for article ID: %s", id)
+	// The load outlives a caller that gives up, so it can still fill the
+	// cache for the requests waiting on it.
+	result := s.loads.DoChan(id, func() (interface{}, error) {
+		return s.loadArticle(context.WithoutCancel(ctx), id)
+	})
+	select {
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		// Callers sharing a load get their own copy to work with.
+		article := *res.Val.(*models.Article)
+		return &article, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// loadArticle reads an article from the database into the cache. When the
+// article is invalidated while the load runs, the result may predate the
+// write, so the entry it cached is dropped again.
+func (s *articleService) loadArticle(ctx context.Context, id string) (*models.Article, error) {
+	version, versionErr := s.articleVersion(ctx, id)
+	article, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if versionErr != nil {
+		log.Printf("Failed to read cache version of article %s, skipping cache: %v", id, versionErr)
+		return article, nil
+	}
+
+	freshFor := articleCacheTTL
+	if s.softTTL > 0 && s.softTTL < articleCacheTTL {
+		freshFor = s.softTTL
+	}
+	jsonData, _ := json.Marshal(articleCacheEntry{Article: article, FreshUntil: time.Now().Add(freshFor)})
+	s.cache.Set(ctx, articleCacheKey(id), jsonData, articleCacheTTL)
+
+	if current, err := s.articleVersion(ctx, id); err != nil || current != version {
+		log.Printf("Article %s changed while loading, dropping cached copy", id)
+		s.cache.Delete(ctx, articleCacheKey(id))
+	}
+	return article, nil
+}
+
+// normalizeListParams rewrites list parameters that produce the same results
+// into one form, so they share a cache entry.
+func normalizeListParams(params models.ListArticlesParams) (models.ListArticlesParams, error) {
+	tags, err := normalizeTags(params.Tags)
+	if err != nil {
+		return params, err
+	}
+	params.Query = strings.ToLower(strings.Join(strings.Fields(params.Query), " "))
+	params.Author = strings.ToLower(params.Author)
+	params.Tags = tags
+	sort.Strings(params.Tags)
+	if len(params.Tags) == 0 {
+		params.TagMode = ""
+	}
+	return params, nil
+}
+
+func articleListCacheKey(generation string, params models.ListArticlesParams) string {
+	key := url.Values{
+		"query":   {params.Query},
+		"author":  {params.Author},
+		"status":  {params.Status},
+		"tag":     params.Tags,
+		"tagMode": {params.TagMode},
+		"viewer":  {params.ViewerID},
+		"limit":   {strconv.Itoa(params.Limit)},
+		"offset":  {strconv.Itoa(params.Offset)},
+	}
+	sum := sha256.Sum256([]byte(key.Encode()))
+	return "articles:list:" + generation + ":" + hex.EncodeToString(sum[:])
+}
+
+// invalidateArticle drops the cached copy of a single article. The version
+// is bumped before the delete, so a load already in flight either sees the new
+// version and drops its own write, or writes before the delete. Later reads
+// start a new load instead of joining one that may have read the old row. Any
+// change to an article can also move it in or out of list results, so the
+// list generation is bumped as well.
+func (s *articleService) invalidateArticle(ctx context.Context, id string) {
+	if _, err := s.versions.Incr(ctx, articleVersionKey(id), articleCacheTTL); err != nil {
+		log.Printf("Failed to bump cache version of article %s: %v", id, err)
+	}
+	if err := s.cache.Delete(ctx, articleCacheKey(id)); err != nil {
+		log.Printf("Failed to invalidate cache of article %s: %v", id, err)
+	}
+	s.loads.Forget(id)
+	s.invalidateArticleLists(ctx)
+}
+
+// invalidateArticleLists bumps the list generation. List results are cached
+// under the generation they were read at, so bumping it retires every cached
+// page at once without touching the keyspace; old pages expire on their TTL.
+func (s *articleService) invalidateArticleLists(ctx context.Context) {
+	if _, err := s.versions.Incr(ctx, articleListGenerationKey, 0); err != nil {
+		log.Printf("Failed to invalidate article list cache: %v", err)
 	}
 }
@@ -0,0 +1,461 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/cache"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (m *MockArticleRepo) Update(ctx context.Context, article *models.Article, editorID string) error {
+	args := m.Called(ctx, article, editorID)
+	return args.Error(0)
+}
+
+func (m *MockArticleRepo) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockArticleRepo) FindAll(ctx context.Context, params models.ListArticlesParams) ([]models.Article, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).([]models.Article), args.Error(1)
+}
+
+func (m *MockArticleRepo) CountAll(ctx context.Context, params models.ListArticlesParams) (int64, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockArticleRepo) Create(ctx context.Context, article *models.Article) error {
+	return m.Called(ctx, article).Error(0)
+}
+
+func (m *MockArticleRepo) SlugTaken(ctx context.Context, slug string, excludeArticleID string) (bool, error) {
+	args := m.Called(ctx, slug, excludeArticleID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockArticleRepo) UpdateStatus(ctx context.Context, article *models.Article) error {
+	return m.Called(ctx, article).Error(0)
+}
+
+func newTestRedis(t testing.TB) (*miniredis.Miniredis, *redis.Client) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return mr, client
+}
+
+func TestArticleService_ChangeStatus(t *testing.T) {
+	ctx := context.Background()
+	author := models.Actor{UserID: "user-1", Role: models.RoleUser}
+	publishedAt := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		from    string
+		change  func(ArticleService, string) (*models.Article, error)
+		want    string
+		wantErr error
+	}{
+		{"draft bisa diterbitkan", models.ArticleStatusDraft, publish, models.ArticleStatusPublished, nil},
+		{"arsip bisa diterbitkan lagi", models.ArticleStatusArchived, publish, models.ArticleStatusPublished, nil},
+		{"artikel terbit tidak bisa diterbitkan lagi", models.ArticleStatusPublished, publish, "", ErrInvalidStatusTransition},
+		{"artikel terbit bisa dikembalikan ke draft", models.ArticleStatusPublished, unpublish, models.ArticleStatusDraft, nil},
+		{"draft tidak bisa dikembalikan ke draft", models.ArticleStatusDraft, unpublish, "", ErrInvalidStatusTransition},
+		{"arsip tidak bisa dikembalikan ke draft", models.ArticleStatusArchived, unpublish, "", ErrInvalidStatusTransition},
+		{"draft bisa diarsipkan", models.ArticleStatusDraft, archive, models.ArticleStatusArchived, nil},
+		{"artikel terbit bisa diarsipkan", models.ArticleStatusPublished, archive, models.ArticleStatusArchived, nil},
+		{"arsip tidak bisa diarsipkan lagi", models.ArticleStatusArchived, archive, "", ErrInvalidStatusTransition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockArticleRepo)
+			service := NewArticleService(repo, cache.NewLRU(0), cache.NewLRU(0), nil, false, 0)
+
+			article := &models.Article{ID: "article-1", AuthorID: "user-1", Status: tt.from}
+			if tt.from == models.ArticleStatusPublished {
+				article.PublishedAt = &publishedAt
+			}
+			repo.On("FindByID", mock.Anything, "article-1").Return(article, nil).Once()
+			repo.On("UpdateStatus", mock.Anything, article).Return(nil).Maybe()
+
+			got, err := tt.change(service, "article-1")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				repo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Status)
+			switch tt.want {
+			case models.ArticleStatusPublished:
+				require.NotNil(t, got.PublishedAt)
+				assert.WithinDuration(t, time.Now(), *got.PublishedAt, time.Second)
+			case models.ArticleStatusDraft:
+				assert.Nil(t, got.PublishedAt)
+			}
+		})
+	}
+
+	t.Run("pengguna lain tidak bisa mengubah status", func(t *testing.T) {
+		repo := new(MockArticleRepo)
+		service := NewArticleService(repo, cache.NewLRU(0), cache.NewLRU(0), nil, false, 0)
+		repo.On("FindByID", mock.Anything, "article-1").Return(&models.Article{ID: "article-1", AuthorID: "user-2", Status: models.ArticleStatusDraft}, nil).Once()
+
+		_, err := service.PublishArticle(ctx, "article-1", author)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+}
+
+func TestArticleService_GetArticleByIDVisibility(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		status  string
+		viewer  models.Actor
+		visible bool
+	}{
+		{"publik melihat artikel terbit", models.ArticleStatusPublished, models.Actor{}, true},
+		{"publik tidak melihat draft", models.ArticleStatusDraft, models.Actor{}, false},
+		{"pengguna lain tidak melihat arsip", models.ArticleStatusArchived, models.Actor{UserID: "user-2", Role: models.RoleUser}, false},
+		{"penulis melihat draft sendiri", models.ArticleStatusDraft, models.Actor{UserID: "user-1", Role: models.RoleUser}, true},
+		{"editor melihat draft", models.ArticleStatusDraft, models.Actor{UserID: "editor", Role: models.RoleEditor}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockArticleRepo)
+			service := NewArticleService(repo, cache.NewLRU(0), cache.NewLRU(0), nil, false, 0)
+			repo.On("FindByID", mock.Anything, "article-1").Return(&models.Article{ID: "article-1", AuthorID: "user-1", Status: tt.status}, nil).Once()
+
+			article, err := service.GetArticleByID(ctx, "article-1", tt.viewer)
+			if tt.visible {
+				require.NoError(t, err)
+				assert.Equal(t, "article-1", article.ID)
+			} else {
+				assert.ErrorIs(t, err, repositories.ErrArticleNotFound)
+			}
+		})
+	}
+}
+
+func publish(s ArticleService, id string) (*models.Article, error) {
+	return s.PublishArticle(context.Background(), id, models.Actor{UserID: "user-1", Role: models.RoleUser})
+}
+
+func unpublish(s ArticleService, id string) (*models.Article, error) {
+	return s.UnpublishArticle(context.Background(), id, models.Actor{UserID: "user-1", Role: models.RoleUser})
+}
+
+func archive(s ArticleService, id string) (*models.Article, error) {
+	return s.ArchiveArticle(context.Background(), id, models.Actor{UserID: "user-1", Role: models.RoleUser})
+}
+
+func TestArticleService_SlugConflict(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("slug yang direbut penulisan lain diganti dengan sufiks berikutnya", func(t *testing.T) {
+		repo := new(MockArticleRepo)
+		service := NewArticleService(repo, cache.NewLRU(0), cache.NewLRU(0), nil, false, 0)
+
+		repo.On("SlugTaken", mock.Anything, "judul", "").Return(false, nil).Once()
+		repo.On("Create", mock.Anything, mock.MatchedBy(func(a *models.Article) bool { return a.Slug == "judul" })).Return(repositories.ErrSlugTaken).Once()
+		repo.On("SlugTaken", mock.Anything, "judul", "").Return(true, nil).Once()
+		repo.On("SlugTaken", mock.Anything, "judul-2", "").Return(false, nil).Once()
+		repo.On("Create", mock.Anything, mock.MatchedBy(func(a *models.Article) bool { return a.Slug == "judul-2" })).Return(nil).Once()
+
+		article, err := service.CreateArticle(ctx, models.CreateArticleRequest{Title: "Judul"}, "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, "judul-2", article.Slug)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("percobaan berhenti setelah batas", func(t *testing.T) {
+		repo := new(MockArticleRepo)
+		service := NewArticleService(repo, cache.NewLRU(0), cache.NewLRU(0), nil, false, 0)
+
+		repo.On("SlugTaken", mock.Anything, mock.Anything, "").Return(false, nil)
+		repo.On("Create", mock.Anything, mock.Anything).Return(repositories.ErrSlugTaken)
+
+		_, err := service.CreateArticle(ctx, models.CreateArticleRequest{Title: "Judul"}, "user-1")
+		assert.ErrorIs(t, err, repositories.ErrSlugTaken)
+		repo.AssertNumberOfCalls(t, "Create", maxSlugAttempts)
+	})
+}
+
+func TestArticleService_CacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+	author := models.Actor{UserID: "user-1", Role: models.RoleUser}
+
+	t.Run("mengubah artikel hanya menghapus cache artikel itu", func(t *testing.T) {
+		mr, client := newTestRedis(t)
+		repo := new(MockArticleRepo)
+		service := NewArticleService(repo, cache.NewRedis(client), cache.NewRedis(client), nil, false, 0)
+
+		require.NoError(t, mr.Set(articleCacheKey("article-2"), "{}"))
+		article := &models.Article{ID: "article-1", Title: "Judul", AuthorID: "user-1", Status: models.ArticleStatusDraft}
+		repo.On("FindByID", mock.Anything, "article-1").Return(article, nil).Once()
+		repo.On("Update", mock.Anything, article, "user-1").Return(nil).Once()
+		require.NoError(t, mr.Set(articleCacheKey("article-1"), "{}"))
+
+		_, err := service.UpdateArticle(ctx, "article-1", models.UpdateArticleRequest{Body: "isi baru"}, author)
+		require.NoError(t, err)
+
+		assert.False(t, mr.Exists(articleCacheKey("article-1")))
+		assert.True(t, mr.Exists(articleCacheKey("article-2")))
+		generation, _ := mr.Get(articleListGenerationKey)
+		assert.Equal(t, "1", generation)
+	})
+
+	t.Run("menghapus artikel menaikkan generasi list", func(t *testing.T) {
+		mr, client := newTestRedis(t)
+		repo := new(MockArticleRepo)
+		service := NewArticleService(repo, cache.NewRedis(client), cache.NewRedis(client), nil, false, 0)
+
+		require.NoError(t, mr.Set(articleListGenerationKey, "4"))
+		require.NoError(t, mr.Set(articleCacheKey("article-1"), "{}"))
+		repo.On("FindByID", mock.Anything, "article-1").Return(&models.Article{ID: "article-1", AuthorID: "user-1"}, nil).Once()
+		repo.On("Delete", mock.Anything, "article-1").Return(nil).Once()
+
+		require.NoError(t, service.DeleteArticle(ctx, "article-1", author))
+
+		assert.False(t, mr.Exists(articleCacheKey("article-1")))
+		generation, _ := mr.Get(articleListGenerationKey)
+		assert.Equal(t, "5", generation)
+	})
+}
+
+func TestArticleService_GetArticlesCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("parameter yang setara memakai cache yang sama", func(t *testing.T) {
+		_, client := newTestRedis(t)
+		repo := new(MockArticleRepo)
+		service := NewArticleService(repo, cache.NewRedis(client), cache.NewRedis(client), nil, false, 0)
+
+		repo.On("FindAll", mock.Anything, mock.Anything).Return([]models.Article{{ID: "article-1"}}, nil).Once()
+		repo.On("CountAll", mock.Anything, mock.Anything).Return(int64(1), nil).Once()
+
+		first, err := service.GetArticles(ctx, models.ListArticlesParams{Query: "golang  cache", Tags: []string{"Go", "redis"}, TagMode: models.TagModeAll, Limit: 10})
+		require.NoError(t, err)
+		second, err := service.GetArticles(ctx, models.ListArticlesParams{Query: "Golang cache", Tags: []string{"redis", " go "}, TagMode: models.TagModeAll, Limit: 10})
+		require.NoError(t, err)
+
+		assert.Equal(t, first, second)
+		repo.AssertNumberOfCalls(t, "FindAll", 1)
+	})
+
+	t.Run("penulisan artikel membuat halaman lama tidak dipakai lagi", func(t *testing.T) {
+		_, client := newTestRedis(t)
+		repo := new(MockArticleRepo)
+		service := NewArticleService(repo, cache.NewRedis(client), cache.NewRedis(client), nil, false, 0)
+		params := models.ListArticlesParams{Limit: 10}
+
+		repo.On("FindAll", mock.Anything, mock.Anything).Return([]models.Article{{ID: "article-1"}}, nil).Once()
+		repo.On("CountAll", mock.Anything, mock.Anything).Return(int64(1), nil).Once()
+		_, err := service.GetArticles(ctx, params)
+		require.NoError(t, err)
+
+		service.(*articleService).invalidateArticleLists(ctx)
+
+		repo.On("FindAll", mock.Anything, mock.Anything).Return([]models.Article{{ID: "article-2"}, {ID: "article-1"}}, nil).Once()
+		repo.On("CountAll", mock.Anything, mock.Anything).Return(int64(2), nil).Once()
+		page, err := service.GetArticles(ctx, params)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), page.Total)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("penulisan di instance lain langsung berlaku dengan cache bertingkat", func(t *testing.T) {
+		_, client := newTestRedis(t)
+		shared := cache.NewRedis(client)
+		repo := new(MockArticleRepo)
+		writer := NewArticleService(repo, cache.NewTiered(cache.NewLRU(100), shared, time.Minute), shared, nil, false, 0)
+		reader := NewArticleService(repo, cache.NewTiered(cache.NewLRU(100), shared, time.Minute), shared, nil, false, 0)
+		params := models.ListArticlesParams{Limit: 10}
+
+		repo.On("FindAll", mock.Anything, mock.Anything).Return([]models.Article{{ID: "article-1"}}, nil).Once()
+		repo.On("CountAll", mock.Anything, mock.Anything).Return(int64(1), nil).Once()
+		_, err := reader.GetArticles(ctx, params)
+		require.NoError(t, err)
+
+		writer.(*articleService).invalidateArticleLists(ctx)
+
+		repo.On("FindAll", mock.Anything, mock.Anything).Return([]models.Article{{ID: "article-2"}, {ID: "article-1"}}, nil).Once()
+		repo.On("CountAll", mock.Anything, mock.Anything).Return(int64(2), nil).Once()
+		page, err := reader.GetArticles(ctx, params)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), page.Total)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("generasi tidak hilang saat cache artikel penuh", func(t *testing.T) {
+		repo := new(MockArticleRepo)
+		versions := cache.NewLRU(0)
+		service := NewArticleService(repo, cache.NewLRU(1), versions, nil, false, 0)
+
+		service.(*articleService).invalidateArticleLists(ctx)
+		repo.On("FindAll", mock.Anything, mock.Anything).Return([]models.Article{}, nil)
+		repo.On("CountAll", mock.Anything, mock.Anything).Return(int64(0), nil)
+		for _, limit := range []int{10, 20, 30} {
+			_, err := service.GetArticles(ctx, models.ListArticlesParams{Limit: limit})
+			require.NoError(t, err)
+		}
+
+		generation, err := versions.Get(ctx, articleListGenerationKey)
+		require.NoError(t, err)
+		assert.Equal(t, "1", string(generation))
+	})
+}
+
+func TestArticleService_GetArticleByIDCache(t *testing.T) {
+	ctx := context.Background()
+	published := &models.Article{ID: "article-1", Title: "Judul", Status: models.ArticleStatusPublished}
+
+	t.Run("permintaan bersamaan saat cache kosong hanya query sekali", func(t *testing.T) {
+		_, client := newTestRedis(t)
+		repo := new(MockArticleRepo)
+		service := NewArticleService(repo, cache.NewRedis(client), cache.NewRedis(client), nil, false, 0)
+
+		repo.On("FindByID", mock.Anything, "article-1").After(50*time.Millisecond).Return(published, nil)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				article, err := service.GetArticleByID(ctx, "article-1", models.Actor{})
+				assert.NoError(t, err)
+				assert.Equal(t, "Judul", article.Title)
+			}()
+		}
+		wg.Wait()
+
+		repo.AssertNumberOfCalls(t, "FindByID", 1)
+	})
+
+	t.Run("entri basi tetap dilayani sambil diperbarui di latar belakang", func(t *testing.T) {
+		mr, client := newTestRedis(t)
+		repo := new(MockArticleRepo)
+		service := NewArticleService(repo, cache.NewRedis(client), cache.NewRedis(client), nil, false, time.Minute)
+
+		stale, _ := json.Marshal(articleCacheEntry{
+			Article:    &models.Article{ID: "article-1", Title: "Judul lama", Status: models.ArticleStatusPublished},
+			FreshUntil: time.Now().Add(-time.Second),
+		})
+		require.NoError(t, mr.Set(articleCacheKey("article-1"), string(stale)))
+		repo.On("FindByID", mock.Anything, "article-1").Return(published, nil).Once()
+
+		article, err := service.GetArticleByID(ctx, "article-1", models.Actor{})
+		require.NoError(t, err)
+		assert.Equal(t, "Judul lama", article.Title)
+
+		assert.Eventually(t, func() bool {
+			val, _ := mr.Get(articleCacheKey("article-1"))
+			var entry articleCacheEntry
+			return json.Unmarshal([]byte(val), &entry) == nil && entry.Article.Title == "Judul" && entry.FreshUntil.After(time.Now())
+		}, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestArticleService_LoadRacingInvalidation(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("muatan yang berjalan saat invalidasi tidak menyimpan artikel lama", func(t *testing.T) {
+		mr, client := newTestRedis(t)
+		repo := new(MockArticleRepo)
+		service := NewArticleService(repo, cache.NewRedis(client), cache.NewRedis(client), nil, false, 0)
+
+		started, release := make(chan struct{}), make(chan struct{})
+		old := &models.Article{ID: "article-1", Title: "Judul lama", Status: models.ArticleStatusPublished}
+		updated := &models.Article{ID: "article-1", Title: "Judul baru", Status: models.ArticleStatusPublished}
+		repo.On("FindByID", mock.Anything, "article-1").Return(old, nil).Run(func(mock.Arguments) {
+			close(started)
+			<-release
+		}).Once()
+		repo.On("FindByID", mock.Anything, "article-1").Return(updated, nil).Once()
+
+		done := make(chan *models.Article)
+		go func() {
+			article, _ := service.GetArticleByID(ctx, "article-1", models.Actor{})
+			done <- article
+		}()
+		<-started
+
+		service.(*articleService).invalidateArticle(ctx, "article-1")
+
+		readCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		article, err := service.GetArticleByID(readCtx, "article-1", models.Actor{})
+		require.NoError(t, err, "pembacaan setelah invalidasi tidak boleh ikut muatan lama")
+		assert.Equal(t, "Judul baru", article.Title)
+
+		close(release)
+		assert.Equal(t, "Judul lama", (<-done).Title)
+		assert.False(t, mr.Exists(articleCacheKey("article-1")), "artikel lama tidak boleh tersimpan di cache")
+		repo.AssertExpectations(t)
+	})
+}
+
+// clearAllArticles is the invalidation the service used before writes only
+// dropped the affected article: a SCAN over the whole keyspace deleting every
+// cached article.
+func clearAllArticles(ctx context.Context, client *redis.Client) {
+	iter := client.Scan(ctx, 0, "article:*", 0).Iterator()
+	for iter.Next(ctx) {
+		client.Del(ctx, iter.Val())
+	}
+}
+
+// BenchmarkArticleCacheInvalidation compares the cost of invalidating the
+// cache after a single write with N articles cached.
+//
+//	go test ./internal/services -run '^$' -bench ArticleCacheInvalidation
+func BenchmarkArticleCacheInvalidation(b *testing.B) {
+	for _, cached := range []int{100, 1000, 10000} {
+		mr, client := newTestRedis(b)
+		service := &articleService{cache: cache.NewRedis(client), versions: cache.NewRedis(client)}
+		fill := func() {
+			for i := 0; i < cached; i++ {
+				mr.Set(articleCacheKey(fmt.Sprint(i)), "{}")
+			}
+		}
+
+		b.Run(fmt.Sprintf("scan-and-delete/%d", cached), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				fill()
+				b.StartTimer()
+				clearAllArticles(context.Background(), client)
+			}
+		})
+
+		b.Run(fmt.Sprintf("per-key/%d", cached), func(b *testing.B) {
+			fill()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				id := fmt.Sprint(i % cached)
+				service.invalidateArticle(context.Background(), id)
+
+				b.StopTimer()
+				mr.Set(articleCacheKey(id), "{}")
+				b.StartTimer()
+			}
+		})
+	}
+}
@@ -4,59 +4,201 @@ import (
 	"context"
 	"errors"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/dhifanrazaqa/kumparan-article/internal/models"
 	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/revocation"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/signing"
 	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
-	"github.com/go-redis/redis"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 var (
 	ErrInvalidCredentials  = errors.New("invalid username or password")
 	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrInvalidChallenge    = errors.New("invalid or expired login challenge, please log in again")
+)
+
+const (
+	challengeAudience = "login-2fa"
+	challengeTTL      = 5 * time.Minute
 )
 
 type AuthService interface {
-	Login(ctx context.Context, req models.LoginRequest) (*models.AuthResponse, error)
+	Login(ctx context.Context, req models.LoginRequest, client models.ClientInfo) (*models.AuthResponse, error)
+	LoginTwoFactor(ctx context.Context, req models.TwoFactorLoginRequest, client models.ClientInfo) (*models.AuthResponse, error)
+	LoginUser(ctx context.Context, user *models.User, scopes []string, client models.ClientInfo) (*models.AuthResponse, error)
 	RefreshToken(ctx context.Context, refreshTokenString string) (*models.AuthResponse, error)
+	Logout(ctx context.Context, refreshTokenString string) error
+	LogoutAll(ctx context.Context, userID string, revokeAPIKeys bool) error
+	UnlockUser(ctx context.Context, userID string) error
+	GetSessions(ctx context.Context, userID string, currentSessionID string) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userID string, sessionID string) error
 }
 
 type authService struct {
-	userRepo           repositories.UserRepository
-	jwtSecret          string
-	refreshTokenSecret string
-	redisClient        *redis.Client
+	userRepo      repositories.UserRepository
+	sessionRepo   repositories.SessionRepository
+	apiKeyRepo    repositories.APIKeyRepository
+	twoFactorRepo repositories.TwoFactorRepository
+	loginAttempts repositories.LoginAttemptRepository
+	accessTokens  signing.Signer
+	refreshTokens signing.SignerVerifier
+	revocations   revocation.Store
 }
 
-func NewAuthService(userRepo repositories.UserRepository, jwtSecret, refreshTokenSecret string, redisClient *redis.Client) AuthService {
+func NewAuthService(userRepo repositories.UserRepository, sessionRepo repositories.SessionRepository, apiKeyRepo repositories.APIKeyRepository, twoFactorRepo repositories.TwoFactorRepository, loginAttempts repositories.LoginAttemptRepository, accessTokens signing.Signer, refreshTokens signing.SignerVerifier, revocations revocation.Store) AuthService {
 	return &authService{
-		userRepo:           userRepo,
-		jwtSecret:          jwtSecret,
-		refreshTokenSecret: refreshTokenSecret,
-		redisClient:        redisClient,
+		userRepo:      userRepo,
+		sessionRepo:   sessionRepo,
+		apiKeyRepo:    apiKeyRepo,
+		twoFactorRepo: twoFactorRepo,
+		loginAttempts: loginAttempts,
+		accessTokens:  accessTokens,
+		refreshTokens: refreshTokens,
+		revocations:   revocations,
 	}
 }
 
-func (s *authService) Login(ctx context.Context, req models.LoginRequest) (*models.AuthResponse, error) {
+func (s *authService) Login(ctx context.Context, req models.LoginRequest, client models.ClientInfo) (*models.AuthResponse, error) {
+	requested := req.Scopes
+	if requested == nil {
+		requested = models.AllScopes
+	}
+	scopes, err := normalizeScopes(requested)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := loginAttempts(req.Username, client.IP)
+	if err := checkLoginThrottle(ctx, s.loginAttempts, attempts); err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.FindByUsername(ctx, req.Username)
 	if err != nil {
+		recordLoginFailure(ctx, s.loginAttempts, attempts)
 		return nil, ErrInvalidCredentials
 	}
 
 	if !utils.CheckPasswordHash(req.Password, user.HashedPassword) {
+		recordLoginFailure(ctx, s.loginAttempts, attempts)
 		return nil, ErrInvalidCredentials
 	}
 
-	accessToken, refreshToken, err := utils.GenerateTokens(user, s.jwtSecret, s.refreshTokenSecret)
+	// Only the username counter is cleared: resetting the address as well
+	// would let an attacker reset it by logging into an account of their own.
+	if err := s.loginAttempts.Reset(ctx, usernameThrottle.key(user.Username)); err != nil {
+		log.Printf("Failed to reset login throttle: %v", err)
+	}
+
+	return s.LoginUser(ctx, user, scopes, client)
+}
+
+// LoginUser finishes the login of a user whose first factor has already been
+// checked, by a password or an external identity provider. Users with
+// two-factor authentication still get a challenge instead of tokens. The
+// scopes are carried into every token of the session.
+func (s *authService) LoginUser(ctx context.Context, user *models.User, scopes []string, client models.ClientInfo) (*models.AuthResponse, error) {
+	tf, err := s.twoFactorRepo.FindByUserID(ctx, user.ID)
+	if err != nil && !errors.Is(err, repositories.ErrTwoFactorNotFound) {
+		return nil, err
+	}
+	if tf != nil && tf.Enabled {
+		return s.issueChallenge(user, scopes)
+	}
+
+	return s.startSession(ctx, user, scopes, client)
+}
+
+// LoginTwoFactor completes a login that Login answered with a challenge. The
+// challenge is single use: a wrong code also burns it, and counts towards the
+// user's two-factor lockout.
+func (s *authService) LoginTwoFactor(ctx context.Context, req models.TwoFactorLoginRequest, client models.ClientInfo) (*models.AuthResponse, error) {
+	claims := &challengeClaims{}
+	token, err := jwt.ParseWithClaims(req.ChallengeToken, claims, s.refreshTokens.Keyfunc, jwt.WithAudience(challengeAudience))
+	if err != nil || !token.Valid || claims.ID == "" || claims.IssuedAt == nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	revoked, err := s.revocations.IsRevoked(ctx, claims.Subject, claims.IssuedAt.Time, claims.ID)
+	if err != nil || revoked {
+		return nil, ErrInvalidChallenge
+	}
+	if err := s.revocations.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return nil, err
+	}
+
+	tf, err := s.twoFactorRepo.FindByUserID(ctx, claims.Subject)
+	if err != nil || !tf.Enabled {
+		return nil, ErrInvalidChallenge
+	}
+	if err := verifyTwoFactorCode(ctx, s.twoFactorRepo, s.loginAttempts, tf, req.Code); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+	scopes, err := normalizeScopes(strings.Fields(claims.Scope))
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+	return s.startSession(ctx, user, scopes, client)
+}
+
+// challengeClaims carries the scopes requested at login through the
+// two-factor step.
+type challengeClaims struct {
+	Scope string `json:"scope,omitempty"`
+	jwt.RegisteredClaims
+}
+
+func (s *authService) issueChallenge(user *models.User, scopes []string) (*models.AuthResponse, error) {
+	now := time.Now()
+	challenge, err := s.refreshTokens.Sign(&challengeClaims{
+		Scope: strings.Join(scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        utils.NewTokenID(),
+			Subject:   user.ID,
+			Audience:  jwt.ClaimStrings{challengeAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(challengeTTL)),
+		},
+	})
 	if err != nil {
 		return nil, errors.New("failed to generate token")
 	}
 
-	err = s.redisClient.Set(refreshToken, user.ID, 7*24*time.Hour).Err()
+	return &models.AuthResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    challenge,
+	}, nil
+}
+
+func (s *authService) startSession(ctx context.Context, user *models.User, scopes []string, client models.ClientInfo) (*models.AuthResponse, error) {
+	now := time.Now()
+	session := &models.Session{
+		ID:             utils.NewTokenID(),
+		UserID:         user.ID,
+		RefreshTokenID: utils.NewTokenID(),
+		UserAgent:      client.UserAgent,
+		IP:             client.IP,
+		CreatedAt:      now,
+		LastUsedAt:     now,
+		Scopes:         scopes,
+	}
+
+	accessToken, refreshToken, err := utils.GenerateTokens(user, s.accessTokens, s.refreshTokens, session.ID, session.RefreshTokenID, session.Scopes)
 	if err != nil {
-		log.Printf("Failed to save refresh token to Redis: %v", err)
+		return nil, errors.New("failed to generate token")
+	}
+
+	if err := s.sessionRepo.Create(ctx, session, utils.RefreshTokenTTL); err != nil {
+		log.Printf("Failed to save session to Redis: %v", err)
 	}
 
 	return &models.AuthResponse{
@@ -66,38 +208,149 @@ func (s *authService) Login(ctx context.Context, req models.LoginRequest) (*mode
 }
 
 func (s *authService) RefreshToken(ctx context.Context, refreshTokenString string) (*models.AuthResponse, error) {
-	token, err := jwt.Parse(refreshTokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(s.refreshTokenSecret), nil
-	})
+	claims, err := s.parseRefreshToken(refreshTokenString)
+	if err != nil {
+		return nil, err
+	}
 
-	if err != nil || !token.Valid {
+	session, err := s.sessionRepo.FindByRefreshTokenID(ctx, claims.ID)
+	if errors.Is(err, repositories.ErrSessionNotFound) {
+		s.detectReuse(ctx, claims)
 		return nil, ErrInvalidRefreshToken
 	}
-
-	userID, err := s.redisClient.Get(refreshTokenString).Result()
-	if err != nil {
+	if err != nil || session.UserID != claims.Subject || session.RefreshTokenID != claims.ID {
 		return nil, ErrInvalidRefreshToken
 	}
 
-	s.redisClient.Del(refreshTokenString)
+	revoked, err := s.revocations.IsRevoked(ctx, session.UserID, claims.IssuedAt.Time)
+	if err != nil || revoked {
+		return nil, ErrInvalidRefreshToken
+	}
 
-	user, err := s.userRepo.FindByID(ctx, userID)
+	user, err := s.userRepo.FindByID(ctx, session.UserID)
 	if err != nil {
 		return nil, ErrInvalidRefreshToken
 	}
 
-	newAccessToken, newRefreshToken, err := utils.GenerateTokens(user, s.jwtSecret, s.refreshTokenSecret)
+	oldRefreshTokenID := session.RefreshTokenID
+	session.RefreshTokenID = utils.NewTokenID()
+	session.LastUsedAt = time.Now()
+
+	newAccessToken, newRefreshToken, err := utils.GenerateTokens(user, s.accessTokens, s.refreshTokens, session.ID, session.RefreshTokenID, session.Scopes)
 	if err != nil {
 		return nil, errors.New("failed to generate new token")
 	}
 
-	err = s.redisClient.Set(newRefreshToken, user.ID, 7*24*time.Hour).Err()
-	if err != nil {
+	if err := s.sessionRepo.Rotate(ctx, session, oldRefreshTokenID, utils.RefreshTokenTTL); err != nil {
+		if errors.Is(err, repositories.ErrSessionNotFound) {
+			s.detectReuse(ctx, claims)
+			return nil, ErrInvalidRefreshToken
+		}
 		log.Printf("Failed to save new refresh token to Redis: %v", err)
+		return nil, errors.New("failed to rotate refresh token")
 	}
 
 	return &models.AuthResponse{
 		AccessToken:  newAccessToken,
 		RefreshToken: newRefreshToken,
 	}, nil
-}
\ No newline at end of file
+}
+
+func (s *authService) Logout(ctx context.Context, refreshTokenString string) error {
+	claims, err := s.parseRefreshToken(refreshTokenString)
+	if err != nil {
+		return err
+	}
+
+	session, err := s.sessionRepo.FindByRefreshTokenID(ctx, claims.ID)
+	if err != nil || session.UserID != claims.Subject {
+		return ErrInvalidRefreshToken
+	}
+
+	return s.endSession(ctx, session)
+}
+
+// LogoutAll ends every session of the user. API keys survive unless
+// revokeAPIKeys is set, since they usually belong to scripts rather than
+// devices.
+func (s *authService) LogoutAll(ctx context.Context, userID string, revokeAPIKeys bool) error {
+	if err := s.sessionRepo.DeleteByUserID(ctx, userID); err != nil {
+		return err
+	}
+	if revokeAPIKeys {
+		if err := s.apiKeyRepo.DeleteByUserID(ctx, userID); err != nil {
+			return err
+		}
+	}
+	return s.revocations.RevokeUser(ctx, userID, time.Now())
+}
+
+func (s *authService) UnlockUser(ctx context.Context, userID string) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.loginAttempts.Reset(ctx, usernameThrottle.key(user.Username)); err != nil {
+		return err
+	}
+	return s.loginAttempts.Reset(ctx, twoFactorThrottle.key(user.ID))
+}
+
+func (s *authService) GetSessions(ctx context.Context, userID string, currentSessionID string) ([]models.Session, error) {
+	sessions, err := s.sessionRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+func (s *authService) RevokeSession(ctx context.Context, userID string, sessionID string) error {
+	session, err := s.sessionRepo.FindByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return repositories.ErrSessionNotFound
+	}
+	return s.endSession(ctx, session)
+}
+
+// detectReuse handles a refresh token that is no longer the current token of
+// its session. If it was rotated before, someone is replaying a stolen token,
+// so the whole rotation family (the session) is ended for both parties.
+func (s *authService) detectReuse(ctx context.Context, claims *jwt.RegisteredClaims) {
+	session, err := s.sessionRepo.FindByRotatedTokenID(ctx, claims.ID)
+	if err != nil || session.UserID != claims.Subject {
+		return
+	}
+
+	log.Printf("SECURITY: refresh token reuse detected (user=%s session=%s token=%s), revoking token family", session.UserID, session.ID, claims.ID)
+	if err := s.endSession(ctx, session); err != nil {
+		log.Printf("Failed to revoke token family of session %s: %v", session.ID, err)
+	}
+}
+
+// endSession deletes the session together with its refresh token and denies
+// the access tokens that were issued for it.
+func (s *authService) endSession(ctx context.Context, session *models.Session) error {
+	if err := s.sessionRepo.Delete(ctx, session); err != nil {
+		return err
+	}
+	if err := s.revocations.RevokeToken(ctx, session.ID, time.Now().Add(utils.AccessTokenTTL)); err != nil {
+		log.Printf("Failed to revoke access tokens of session: %v", err)
+	}
+	return nil
+}
+
+func (s *authService) parseRefreshToken(refreshTokenString string) (*jwt.RegisteredClaims, error) {
+	claims := &jwt.RegisteredClaims{}
+	token, err := jwt.ParseWithClaims(refreshTokenString, claims, s.refreshTokens.Keyfunc)
+
+	if err != nil || !token.Valid || claims.ID == "" || claims.IssuedAt == nil || len(claims.Audience) > 0 {
+		return nil, ErrInvalidRefreshToken
+	}
+	return claims, nil
+}
@@ -0,0 +1,362 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/cache"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/revocation"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/signing"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockSessionRepo struct {
+	mock.Mock
+	repositories.SessionRepository
+}
+
+func (m *MockSessionRepo) FindByRefreshTokenID(ctx context.Context, refreshTokenID string) (*models.Session, error) {
+	args := m.Called(ctx, refreshTokenID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepo) FindByRotatedTokenID(ctx context.Context, refreshTokenID string) (*models.Session, error) {
+	args := m.Called(ctx, refreshTokenID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepo) Create(ctx context.Context, session *models.Session, ttl time.Duration) error {
+	args := m.Called(ctx, session, ttl)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepo) Delete(ctx context.Context, session *models.Session) error {
+	args := m.Called(ctx, session)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepo) Rotate(ctx context.Context, session *models.Session, oldRefreshTokenID string, ttl time.Duration) error {
+	args := m.Called(ctx, session, oldRefreshTokenID, ttl)
+	return args.Error(0)
+}
+
+type MockLoginAttemptRepo struct {
+	mock.Mock
+	repositories.LoginAttemptRepository
+}
+
+func (m *MockLoginAttemptRepo) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+func (m *MockLoginAttemptRepo) RecordFailure(ctx context.Context, key string, window time.Duration) (int64, error) {
+	args := m.Called(ctx, key, window)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLoginAttemptRepo) Lock(ctx context.Context, key string, duration time.Duration) error {
+	args := m.Called(ctx, key, duration)
+	return args.Error(0)
+}
+
+func TestAuthService_Login_Throttle(t *testing.T) {
+	client := models.ClientInfo{IP: "10.0.0.1"}
+	req := models.LoginRequest{Username: "budi", Password: "wrong-password"}
+
+	t.Run("login yang terkunci ditolak sebelum password diperiksa", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		attempts := new(MockLoginAttemptRepo)
+		authService := NewAuthService(userRepo, nil, nil, nil, attempts, nil, nil, nil)
+
+		attempts.On("LockedFor", mock.Anything, "user:budi").Return(90*time.Second, nil).Once()
+		attempts.On("LockedFor", mock.Anything, "ip:10.0.0.1").Return(time.Duration(0), nil).Once()
+
+		resp, err := authService.Login(context.Background(), req, client)
+
+		var throttled *TooManyAttemptsError
+		assert.ErrorAs(t, err, &throttled)
+		assert.Equal(t, 90*time.Second, throttled.RetryAfter)
+		assert.Nil(t, resp)
+		userRepo.AssertNotCalled(t, "FindByUsername", mock.Anything, mock.Anything)
+	})
+
+	t.Run("kegagalan melewati batas mengunci username", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		attempts := new(MockLoginAttemptRepo)
+		authService := NewAuthService(userRepo, nil, nil, nil, attempts, nil, nil, nil)
+
+		attempts.On("LockedFor", mock.Anything, mock.Anything).Return(time.Duration(0), nil)
+		userRepo.On("FindByUsername", mock.Anything, "budi").Return(&models.User{ID: "user-1", Username: "budi", HashedPassword: "not-a-hash"}, nil).Once()
+		attempts.On("RecordFailure", mock.Anything, "user:budi", loginFailureWindow).Return(int64(6), nil).Once()
+		attempts.On("RecordFailure", mock.Anything, "ip:10.0.0.1", loginFailureWindow).Return(int64(6), nil).Once()
+		attempts.On("Lock", mock.Anything, "user:budi", 30*time.Second).Return(nil).Once()
+
+		resp, err := authService.Login(context.Background(), req, client)
+
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		assert.Nil(t, resp)
+		attempts.AssertExpectations(t)
+		attempts.AssertNotCalled(t, "Lock", mock.Anything, "ip:10.0.0.1", mock.Anything)
+	})
+}
+
+func TestAuthService_Login_Scopes(t *testing.T) {
+	accessKey := signing.NewHMACKey([]byte("access-secret"))
+	refreshKey := signing.NewHMACKey([]byte("refresh-secret"))
+	user := &models.User{ID: "user-1", Username: "budi", Role: models.RoleUser}
+
+	t.Run("scope yang diminta dibawa ke access token dan sesi", func(t *testing.T) {
+		sessionRepo, twoFactorRepo := new(MockSessionRepo), new(MockTwoFactorRepo)
+		authService := NewAuthService(nil, sessionRepo, nil, twoFactorRepo, nil, accessKey, refreshKey, nil)
+
+		twoFactorRepo.On("FindByUserID", mock.Anything, "user-1").Return(nil, repositories.ErrTwoFactorNotFound).Once()
+		sessionRepo.On("Create", mock.Anything, mock.MatchedBy(func(s *models.Session) bool {
+			return len(s.Scopes) == 1 && s.Scopes[0] == models.ScopeArticlesWrite
+		}), utils.RefreshTokenTTL).Return(nil).Once()
+
+		resp, err := authService.LoginUser(context.Background(), user, []string{models.ScopeArticlesWrite}, models.ClientInfo{})
+		require.NoError(t, err)
+
+		claims := &models.Claims{}
+		_, err = jwt.ParseWithClaims(resp.AccessToken, claims, accessKey.Keyfunc)
+		require.NoError(t, err)
+		assert.True(t, claims.HasScope(models.ScopeArticlesWrite))
+		assert.False(t, claims.HasScope(models.ScopeUsersWrite))
+		sessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("login tanpa scope menghasilkan token hanya-baca", func(t *testing.T) {
+		sessionRepo, twoFactorRepo := new(MockSessionRepo), new(MockTwoFactorRepo)
+		authService := NewAuthService(nil, sessionRepo, nil, twoFactorRepo, nil, accessKey, refreshKey, nil)
+
+		twoFactorRepo.On("FindByUserID", mock.Anything, "user-1").Return(nil, repositories.ErrTwoFactorNotFound).Once()
+		sessionRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Session"), utils.RefreshTokenTTL).Return(nil).Once()
+
+		resp, err := authService.LoginUser(context.Background(), user, []string{}, models.ClientInfo{})
+		require.NoError(t, err)
+
+		claims := &models.Claims{}
+		_, err = jwt.ParseWithClaims(resp.AccessToken, claims, accessKey.Keyfunc)
+		require.NoError(t, err)
+		assert.Empty(t, claims.Scopes())
+	})
+
+	t.Run("scope yang tidak dikenal ditolak sebelum password diperiksa", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		authService := NewAuthService(userRepo, nil, nil, nil, nil, accessKey, refreshKey, nil)
+
+		_, err := authService.Login(context.Background(), models.LoginRequest{Username: "budi", Password: "x", Scopes: []string{"root"}}, models.ClientInfo{})
+		assert.ErrorIs(t, err, ErrInvalidScope)
+		userRepo.AssertNotCalled(t, "FindByUsername", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthService_LoginTwoFactor_Throttle(t *testing.T) {
+	refreshKey := signing.NewHMACKey([]byte("refresh-secret"))
+	user := &models.User{ID: "user-1", Username: "budi", Role: models.RoleUser}
+	twoFactorRepo := new(MockTwoFactorRepo)
+	authService := NewAuthService(nil, nil, nil, twoFactorRepo, repositories.NewLoginAttemptRepo(cache.NewLRU(0)), nil, refreshKey, revocation.NewMemoryStore(revocation.DefaultRetention))
+
+	twoFactorRepo.On("FindByUserID", mock.Anything, "user-1").Return(&models.TwoFactor{UserID: "user-1", Secret: "JBSWY3DPEHPK3PXP", Enabled: true}, nil)
+	twoFactorRepo.On("UseRecoveryCode", mock.Anything, "user-1", mock.AnythingOfType("string")).Return(false, nil)
+
+	// Every round starts from a fresh challenge, as a caller who knows the
+	// password or signs in through an identity provider would.
+	guess := func() error {
+		challenge, err := authService.LoginUser(context.Background(), user, nil, models.ClientInfo{})
+		require.NoError(t, err)
+		require.True(t, challenge.TwoFactorRequired)
+		_, err = authService.LoginTwoFactor(context.Background(), models.TwoFactorLoginRequest{ChallengeToken: challenge.ChallengeToken, Code: "tebakan"}, models.ClientInfo{})
+		return err
+	}
+
+	for i := int64(0); i <= twoFactorThrottle.freeAttempts; i++ {
+		assert.ErrorIs(t, guess(), ErrInvalidTwoFactorCode)
+	}
+
+	t.Run("kode salah berulang mengunci 2FA walau tantangannya baru", func(t *testing.T) {
+		var throttled *TooManyAttemptsError
+		assert.ErrorAs(t, guess(), &throttled)
+		twoFactorRepo.AssertNumberOfCalls(t, "UseRecoveryCode", int(twoFactorThrottle.freeAttempts)+1)
+	})
+}
+
+func TestThrottleRule_LockDuration(t *testing.T) {
+	rule := throttleRule{freeAttempts: 5, baseLock: 30 * time.Second, maxLock: 5 * time.Minute}
+
+	assert.Equal(t, time.Duration(0), rule.lockDuration(5))
+	assert.Equal(t, 30*time.Second, rule.lockDuration(6))
+	assert.Equal(t, time.Minute, rule.lockDuration(7))
+	assert.Equal(t, 4*time.Minute, rule.lockDuration(9))
+	assert.Equal(t, 5*time.Minute, rule.lockDuration(10))
+	assert.Equal(t, 5*time.Minute, rule.lockDuration(100))
+}
+
+func TestAuthService_RefreshToken(t *testing.T) {
+	accessKey := signing.NewHMACKey([]byte("access-secret"))
+	refreshKey := signing.NewHMACKey([]byte("refresh-secret"))
+	user := &models.User{ID: "user-1", Username: "user", Role: models.RoleUser}
+
+	t.Run("pemakaian ulang token yang sudah dirotasi mencabut seluruh family", func(t *testing.T) {
+		sessionRepo := new(MockSessionRepo)
+		revocations := revocation.NewMemoryStore(revocation.DefaultRetention)
+		authService := NewAuthService(new(MockUserRepo), sessionRepo, nil, nil, nil, accessKey, refreshKey, revocations)
+
+		_, refreshToken, err := utils.GenerateTokens(user, accessKey, refreshKey, "session-1", "rotated-jti", nil)
+		assert.NoError(t, err)
+
+		session := &models.Session{ID: "session-1", UserID: user.ID, RefreshTokenID: "current-jti"}
+		sessionRepo.On("FindByRefreshTokenID", mock.Anything, "rotated-jti").Return(nil, repositories.ErrSessionNotFound).Once()
+		sessionRepo.On("FindByRotatedTokenID", mock.Anything, "rotated-jti").Return(session, nil).Once()
+		sessionRepo.On("Delete", mock.Anything, session).Return(nil).Once()
+
+		resp, err := authService.RefreshToken(context.Background(), refreshToken)
+
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+		assert.Nil(t, resp)
+		revoked, err := revocations.IsRevoked(context.Background(), user.ID, time.Now(), "session-1")
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+		sessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("refresh yang sah merotasi token di sesi yang sama", func(t *testing.T) {
+		userRepo, sessionRepo := new(MockUserRepo), new(MockSessionRepo)
+		authService := NewAuthService(userRepo, sessionRepo, nil, nil, nil, accessKey, refreshKey, revocation.NewMemoryStore(revocation.DefaultRetention))
+
+		_, refreshToken, err := utils.GenerateTokens(user, accessKey, refreshKey, "session-1", "current-jti", nil)
+		require.NoError(t, err)
+
+		session := &models.Session{ID: "session-1", UserID: user.ID, RefreshTokenID: "current-jti"}
+		sessionRepo.On("FindByRefreshTokenID", mock.Anything, "current-jti").Return(session, nil).Once()
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil).Once()
+		sessionRepo.On("Rotate", mock.Anything, session, "current-jti", utils.RefreshTokenTTL).Return(nil).Once()
+
+		resp, err := authService.RefreshToken(context.Background(), refreshToken)
+		require.NoError(t, err)
+		assert.NotEqual(t, "current-jti", session.RefreshTokenID)
+
+		claims := &jwt.RegisteredClaims{}
+		_, err = jwt.ParseWithClaims(resp.RefreshToken, claims, refreshKey.Keyfunc)
+		require.NoError(t, err)
+		assert.Equal(t, session.RefreshTokenID, claims.ID)
+		sessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("token tidak dikenal tidak mencabut sesi apa pun", func(t *testing.T) {
+		sessionRepo := new(MockSessionRepo)
+		authService := NewAuthService(new(MockUserRepo), sessionRepo, nil, nil, nil, accessKey, refreshKey, revocation.NewMemoryStore(revocation.DefaultRetention))
+
+		_, refreshToken, err := utils.GenerateTokens(user, accessKey, refreshKey, "session-2", "unknown-jti", nil)
+		assert.NoError(t, err)
+
+		sessionRepo.On("FindByRefreshTokenID", mock.Anything, "unknown-jti").Return(nil, repositories.ErrSessionNotFound).Once()
+		sessionRepo.On("FindByRotatedTokenID", mock.Anything, "unknown-jti").Return(nil, repositories.ErrSessionNotFound).Once()
+
+		resp, err := authService.RefreshToken(context.Background(), refreshToken)
+
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+		assert.Nil(t, resp)
+		sessionRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+		sessionRepo.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	accessKey := signing.NewHMACKey([]byte("access-secret"))
+	refreshKey := signing.NewHMACKey([]byte("refresh-secret"))
+	user := &models.User{ID: "user-1", Username: "user", Role: models.RoleUser}
+
+	t.Run("logout mengakhiri sesi dan mencabut access token-nya", func(t *testing.T) {
+		sessionRepo := new(MockSessionRepo)
+		revocations := revocation.NewMemoryStore(revocation.DefaultRetention)
+		authService := NewAuthService(nil, sessionRepo, nil, nil, nil, accessKey, refreshKey, revocations)
+
+		_, refreshToken, err := utils.GenerateTokens(user, accessKey, refreshKey, "session-1", "current-jti", nil)
+		require.NoError(t, err)
+
+		session := &models.Session{ID: "session-1", UserID: user.ID, RefreshTokenID: "current-jti"}
+		sessionRepo.On("FindByRefreshTokenID", mock.Anything, "current-jti").Return(session, nil).Once()
+		sessionRepo.On("Delete", mock.Anything, session).Return(nil).Once()
+
+		require.NoError(t, authService.Logout(context.Background(), refreshToken))
+
+		revoked, err := revocations.IsRevoked(context.Background(), user.ID, time.Now(), "session-1")
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+		sessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("refresh token milik pengguna lain ditolak", func(t *testing.T) {
+		sessionRepo := new(MockSessionRepo)
+		authService := NewAuthService(nil, sessionRepo, nil, nil, nil, accessKey, refreshKey, revocation.NewMemoryStore(revocation.DefaultRetention))
+
+		_, refreshToken, err := utils.GenerateTokens(user, accessKey, refreshKey, "session-1", "current-jti", nil)
+		require.NoError(t, err)
+
+		sessionRepo.On("FindByRefreshTokenID", mock.Anything, "current-jti").Return(&models.Session{ID: "session-1", UserID: "user-2"}, nil).Once()
+
+		err = authService.Logout(context.Background(), refreshToken)
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+		sessionRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("token yang bukan refresh token ditolak", func(t *testing.T) {
+		sessionRepo := new(MockSessionRepo)
+		authService := NewAuthService(nil, sessionRepo, nil, nil, nil, accessKey, refreshKey, revocation.NewMemoryStore(revocation.DefaultRetention))
+
+		accessToken, _, err := utils.GenerateTokens(user, accessKey, refreshKey, "session-1", "current-jti", nil)
+		require.NoError(t, err)
+
+		err = authService.Logout(context.Background(), accessToken)
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+		sessionRepo.AssertNotCalled(t, "FindByRefreshTokenID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("logout semua perangkat menghapus semua sesi dan mencabut token", func(t *testing.T) {
+		sessionRepo := new(MockSessionRepo)
+		revocations := revocation.NewMemoryStore(revocation.DefaultRetention)
+		authService := NewAuthService(nil, sessionRepo, nil, nil, nil, accessKey, refreshKey, revocations)
+
+		sessionRepo.On("DeleteByUserID", mock.Anything, user.ID).Return(nil).Once()
+
+		issuedAt := time.Now().Add(-time.Minute)
+		require.NoError(t, authService.LogoutAll(context.Background(), user.ID, false))
+
+		revoked, err := revocations.IsRevoked(context.Background(), user.ID, issuedAt)
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+		sessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("logout semua perangkat bisa sekaligus mencabut API key", func(t *testing.T) {
+		sessionRepo, apiKeyRepo := new(MockSessionRepo), new(MockAPIKeyRepo)
+		authService := NewAuthService(nil, sessionRepo, apiKeyRepo, nil, nil, accessKey, refreshKey, revocation.NewMemoryStore(revocation.DefaultRetention))
+
+		sessionRepo.On("DeleteByUserID", mock.Anything, user.ID).Return(nil).Once()
+		apiKeyRepo.On("DeleteByUserID", mock.Anything, user.ID).Return(nil).Once()
+
+		require.NoError(t, authService.LogoutAll(context.Background(), user.ID, true))
+		sessionRepo.AssertExpectations(t)
+		apiKeyRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/policy"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
+	"golang.org/x/sync/errgroup"
+)
+
+var ErrInvalidParentComment = errors.New("replies can only be made to top-level comments of the same article")
+
+type CommentService interface {
+	GetComments(ctx context.Context, params models.ListCommentsParams) (*models.PaginatedComments, error)
+	CreateComment(ctx context.Context, articleID string, req models.CreateCommentRequest, actor models.Actor) (*models.Comment, error)
+	UpdateComment(ctx context.Context, articleID, commentID string, req models.UpdateCommentRequest, actor models.Actor) (*models.Comment, error)
+	DeleteComment(ctx context.Context, articleID, commentID string, actor models.Actor) error
+}
+
+type commentService struct {
+	repo        repositories.CommentRepository
+	articleRepo repositories.ArticleRepository
+}
+
+func NewCommentService(repo repositories.CommentRepository, articleRepo repositories.ArticleRepository) CommentService {
+	return &commentService{repo: repo, articleRepo: articleRepo}
+}
+
+func (s *commentService) GetComments(ctx context.Context, params models.ListCommentsParams) (*models.PaginatedComments, error) {
+	if _, err := s.findVisibleArticle(ctx, params.ArticleID, params.Viewer); err != nil {
+		return nil, err
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+
+	var comments []models.Comment
+	var total int64
+
+	g.Go(func() error {
+		var err error
+		comments, err = s.repo.FindByArticleID(ctx, params)
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		total, err = s.repo.CountByArticleID(ctx, params.ArticleID)
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	totalPages := 0
+	if total > 0 && params.Limit > 0 {
+		totalPages = int((total + int64(params.Limit) - 1) / int64(params.Limit))
+	}
+
+	currentPage := 1
+	if params.Limit > 0 {
+		currentPage = (params.Offset / params.Limit) + 1
+	}
+
+	return &models.PaginatedComments{
+		Data:       comments,
+		Total:      total,
+		Page:       currentPage,
+		Limit:      params.Limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+func (s *commentService) CreateComment(ctx context.Context, articleID string, req models.CreateCommentRequest, actor models.Actor) (*models.Comment, error) {
+	if _, err := s.findVisibleArticle(ctx, articleID, actor); err != nil {
+		return nil, err
+	}
+
+	if req.ParentID != nil {
+		if !utils.IsValidUUID(*req.ParentID) {
+			return nil, ErrInvalidParentComment
+		}
+		parent, err := s.repo.FindByID(ctx, *req.ParentID)
+		if err != nil {
+			if errors.Is(err, repositories.ErrCommentNotFound) {
+				return nil, ErrInvalidParentComment
+			}
+			return nil, err
+		}
+		if parent.ArticleID != articleID || parent.ParentID != nil {
+			return nil, ErrInvalidParentComment
+		}
+	}
+
+	comment := &models.Comment{
+		ArticleID: articleID,
+		ParentID:  req.ParentID,
+		AuthorID:  actor.UserID,
+		Body:      req.Body,
+	}
+	if err := s.repo.Create(ctx, comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (s *commentService) UpdateComment(ctx context.Context, articleID, commentID string, req models.UpdateCommentRequest, actor models.Actor) (*models.Comment, error) {
+	comment, err := s.findComment(ctx, articleID, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !policy.CanEditComment(actor, comment) {
+		return nil, ErrForbidden
+	}
+
+	comment.Body = req.Body
+	if err := s.repo.Update(ctx, comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (s *commentService) DeleteComment(ctx context.Context, articleID, commentID string, actor models.Actor) error {
+	comment, err := s.findComment(ctx, articleID, commentID)
+	if err != nil {
+		return err
+	}
+
+	article, err := s.articleRepo.FindByID(ctx, articleID)
+	if err != nil {
+		return err
+	}
+	if !policy.CanDeleteComment(actor, comment, article) {
+		return ErrForbidden
+	}
+
+	return s.repo.Delete(ctx, commentID)
+}
+
+func (s *commentService) findComment(ctx context.Context, articleID, commentID string) (*models.Comment, error) {
+	comment, err := s.repo.FindByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.ArticleID != articleID {
+		return nil, repositories.ErrCommentNotFound
+	}
+	return comment, nil
+}
+
+func (s *commentService) findVisibleArticle(ctx context.Context, articleID string, viewer models.Actor) (*models.Article, error) {
+	article, err := s.articleRepo.FindByID(ctx, articleID)
+	if err != nil {
+		return nil, err
+	}
+	if !policy.CanViewArticle(viewer, article) {
+		return nil, repositories.ErrArticleNotFound
+	}
+	return article, nil
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockArticleRepo struct {
+	mock.Mock
+	repositories.ArticleRepository
+}
+
+func (m *MockArticleRepo) FindByID(ctx context.Context, id string) (*models.Article, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Article), args.Error(1)
+}
+
+type MockCommentRepo struct {
+	mock.Mock
+	repositories.CommentRepository
+}
+
+func (m *MockCommentRepo) Create(ctx context.Context, comment *models.Comment) error {
+	return m.Called(ctx, comment).Error(0)
+}
+
+func (m *MockCommentRepo) FindByID(ctx context.Context, id string) (*models.Comment, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepo) Delete(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func TestCommentService_CreateComment(t *testing.T) {
+	article := &models.Article{ID: "article-1", AuthorID: "author", Status: models.ArticleStatusPublished}
+
+	t.Run("gagal membalas dengan parentId yang bukan UUID", func(t *testing.T) {
+		articleRepo := new(MockArticleRepo)
+		commentRepo := new(MockCommentRepo)
+		service := NewCommentService(commentRepo, articleRepo)
+
+		parentID := "bukan-uuid"
+		articleRepo.On("FindByID", mock.Anything, "article-1").Return(article, nil).Once()
+
+		_, err := service.CreateComment(context.Background(), "article-1", models.CreateCommentRequest{Body: "halo", ParentID: &parentID}, models.Actor{UserID: "reader"})
+		assert.ErrorIs(t, err, ErrInvalidParentComment)
+		commentRepo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("sukses membalas komentar utama", func(t *testing.T) {
+		articleRepo := new(MockArticleRepo)
+		commentRepo := new(MockCommentRepo)
+		service := NewCommentService(commentRepo, articleRepo)
+
+		parentID := "0b6f3c1e-5a2d-4e8f-9c7b-1d2e3f4a5b6c"
+		articleRepo.On("FindByID", mock.Anything, "article-1").Return(article, nil).Once()
+		commentRepo.On("FindByID", mock.Anything, parentID).Return(&models.Comment{ID: parentID, ArticleID: "article-1"}, nil).Once()
+		commentRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Comment")).Return(nil).Once()
+
+		comment, err := service.CreateComment(context.Background(), "article-1", models.CreateCommentRequest{Body: "halo", ParentID: &parentID}, models.Actor{UserID: "reader"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &parentID, comment.ParentID)
+		commentRepo.AssertExpectations(t)
+	})
+
+	t.Run("gagal membalas sebuah balasan", func(t *testing.T) {
+		articleRepo := new(MockArticleRepo)
+		commentRepo := new(MockCommentRepo)
+		service := NewCommentService(commentRepo, articleRepo)
+
+		grandparent := "grandparent"
+		articleRepo.On("FindByID", mock.Anything, "article-1").Return(article, nil).Once()
+		replyID := "7d9e1f2a-3b4c-4d5e-8f6a-7b8c9d0e1f2a"
+		commentRepo.On("FindByID", mock.Anything, replyID).Return(&models.Comment{ID: replyID, ArticleID: "article-1", ParentID: &grandparent}, nil).Once()
+
+		_, err := service.CreateComment(context.Background(), "article-1", models.CreateCommentRequest{Body: "halo", ParentID: &replyID}, models.Actor{UserID: "reader"})
+
+		assert.Equal(t, ErrInvalidParentComment, err)
+		commentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("gagal berkomentar di draft milik orang lain", func(t *testing.T) {
+		articleRepo := new(MockArticleRepo)
+		commentRepo := new(MockCommentRepo)
+		service := NewCommentService(commentRepo, articleRepo)
+
+		draft := &models.Article{ID: "article-2", AuthorID: "author", Status: models.ArticleStatusDraft}
+		articleRepo.On("FindByID", mock.Anything, "article-2").Return(draft, nil).Once()
+
+		_, err := service.CreateComment(context.Background(), "article-2", models.CreateCommentRequest{Body: "halo"}, models.Actor{UserID: "reader"})
+
+		assert.Equal(t, repositories.ErrArticleNotFound, err)
+	})
+}
+
+func TestCommentService_DeleteComment(t *testing.T) {
+	article := &models.Article{ID: "article-1", AuthorID: "author", Status: models.ArticleStatusPublished}
+	comment := &models.Comment{ID: "comment-1", ArticleID: "article-1", AuthorID: "reader"}
+
+	t.Run("penulis artikel boleh menghapus komentar", func(t *testing.T) {
+		articleRepo := new(MockArticleRepo)
+		commentRepo := new(MockCommentRepo)
+		service := NewCommentService(commentRepo, articleRepo)
+
+		commentRepo.On("FindByID", mock.Anything, "comment-1").Return(comment, nil).Once()
+		articleRepo.On("FindByID", mock.Anything, "article-1").Return(article, nil).Once()
+		commentRepo.On("Delete", mock.Anything, "comment-1").Return(nil).Once()
+
+		err := service.DeleteComment(context.Background(), "article-1", "comment-1", models.Actor{UserID: "author"})
+
+		assert.NoError(t, err)
+		commentRepo.AssertExpectations(t)
+	})
+
+	t.Run("pengguna lain tidak boleh menghapus komentar", func(t *testing.T) {
+		articleRepo := new(MockArticleRepo)
+		commentRepo := new(MockCommentRepo)
+		service := NewCommentService(commentRepo, articleRepo)
+
+		commentRepo.On("FindByID", mock.Anything, "comment-1").Return(comment, nil).Once()
+		articleRepo.On("FindByID", mock.Anything, "article-1").Return(article, nil).Once()
+
+		err := service.DeleteComment(context.Background(), "article-1", "comment-1", models.Actor{UserID: "stranger"})
+
+		assert.Equal(t, ErrForbidden, err)
+		commentRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/mailer"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
+)
+
+const emailVerificationTTL = 24 * time.Hour
+
+// VerifyEmail marks the address a verification token was sent to as verified,
+// provided the user has not changed their address since.
+func (s *userService) VerifyEmail(ctx context.Context, token string) error {
+	userID, email, err := s.verificationRepo.Consume(ctx, utils.HashSecretToken(token))
+	if err != nil {
+		return err
+	}
+
+	err = s.userRepo.MarkEmailVerified(ctx, userID, email)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return repositories.ErrEmailVerificationTokenInvalid
+	}
+	return err
+}
+
+// sendVerificationEmail is best effort: the account change has already been
+// saved, so failures are only logged.
+func (s *userService) sendVerificationEmail(ctx context.Context, user *models.User) {
+	token, hash := utils.NewSecretToken()
+	if err := s.verificationRepo.Create(ctx, user.ID, user.Email, hash, time.Now().Add(emailVerificationTTL)); err != nil {
+		log.Printf("Failed to create email verification token: %v", err)
+		return
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nSend the token below to POST /users/verify-email within %d hours to verify this address:\n\n%s\n",
+			user.Name, int(emailVerificationTTL.Hours()), token),
+	}
+	go func() {
+		sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailSendTimeout)
+		defer cancel()
+		if err := s.mailer.Send(sendCtx, msg); err != nil {
+			log.Printf("Failed to send verification email: %v", err)
+		}
+	}()
+}
+
+// sendEmailChangedNotice tells the previous address that the account moved to
+// a new one, so the owner notices when someone else changed it.
+func (s *userService) sendEmailChangedNotice(ctx context.Context, previousEmail string, user *models.User) {
+	msg := mailer.Message{
+		To:      previousEmail,
+		Subject: "Your email address was changed",
+		Body: fmt.Sprintf("Hi %s,\n\nThe email address of your account %q was changed from this address to %s. "+
+			"If this was not you, reset your password and contact support.\n",
+			user.Name, user.Username, user.Email),
+	}
+	go func() {
+		sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailSendTimeout)
+		defer cancel()
+		if err := s.mailer.Send(sendCtx, msg); err != nil {
+			log.Printf("Failed to send email change notice: %v", err)
+		}
+	}()
+}
+
+func emailVerified(user *models.User) *bool {
+	if user.Email == "" {
+		return nil
+	}
+	verified := user.EmailVerifiedAt != nil
+	return &verified
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/oidc"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
+)
+
+var (
+	ErrUnknownProvider      = errors.New("unknown identity provider")
+	ErrInvalidOIDCState     = errors.New("invalid or expired login state, please start the login again")
+	ErrOIDCLoginFailed      = errors.New("could not sign in with the identity provider")
+	ErrOIDCEmailNotVerified = errors.New("an account with this email address already exists, verify the address before signing in with an identity provider")
+)
+
+const (
+	oidcStateTTL      = 10 * time.Minute
+	maxUsernameLength = 40
+)
+
+type OIDCService interface {
+	StartLogin(ctx context.Context, provider string) (authURL string, state string, err error)
+	CompleteLogin(ctx context.Context, provider string, state string, code string, client models.ClientInfo) (*models.AuthResponse, error)
+}
+
+type oidcService struct {
+	providers    map[string]*oidc.Provider
+	stateRepo    repositories.OIDCStateRepository
+	identityRepo repositories.ExternalIdentityRepository
+	userRepo     repositories.UserRepository
+	authService  AuthService
+}
+
+func NewOIDCService(providers map[string]*oidc.Provider, stateRepo repositories.OIDCStateRepository, identityRepo repositories.ExternalIdentityRepository, userRepo repositories.UserRepository, authService AuthService) OIDCService {
+	return &oidcService{
+		providers:    providers,
+		stateRepo:    stateRepo,
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+		authService:  authService,
+	}
+}
+
+func (s *oidcService) StartLogin(ctx context.Context, providerName string) (string, string, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+
+	state := oidc.NewCodeVerifier()
+	login := &models.OIDCLoginState{
+		Provider:     providerName,
+		Nonce:        oidc.NewCodeVerifier(),
+		CodeVerifier: oidc.NewCodeVerifier(),
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, login.Nonce, login.CodeVerifier)
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.stateRepo.Save(ctx, state, login, oidcStateTTL); err != nil {
+		return "", "", err
+	}
+	return authURL, state, nil
+}
+
+func (s *oidcService) CompleteLogin(ctx context.Context, providerName string, state string, code string, client models.ClientInfo) (*models.AuthResponse, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	login, err := s.stateRepo.Consume(ctx, state)
+	if errors.Is(err, repositories.ErrOIDCStateNotFound) {
+		return nil, ErrInvalidOIDCState
+	}
+	if err != nil {
+		return nil, err
+	}
+	if login.Provider != providerName {
+		return nil, ErrInvalidOIDCState
+	}
+
+	idToken, err := provider.Authenticate(ctx, code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		log.Printf("OIDC login with %s failed: %v", providerName, err)
+		return nil, ErrOIDCLoginFailed
+	}
+
+	user, err := s.resolveUser(ctx, providerName, idToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.authService.LoginUser(ctx, user, models.AllScopes, client)
+}
+
+// resolveUser finds the local user of an external identity. Unknown
+// identities are linked to the account with the same email address when both
+// the provider and this service have verified it, and get a new account
+// otherwise.
+func (s *oidcService) resolveUser(ctx context.Context, provider string, idToken *oidc.IDToken) (*models.User, error) {
+	identity, err := s.identityRepo.Find(ctx, provider, idToken.Subject)
+	if err == nil {
+		return s.userRepo.FindByID(ctx, identity.UserID)
+	}
+	if !errors.Is(err, repositories.ErrExternalIdentityNotFound) {
+		return nil, err
+	}
+
+	var user *models.User
+	if idToken.Email != "" && idToken.EmailVerified {
+		existing, err := s.userRepo.FindByEmail(ctx, idToken.Email)
+		switch {
+		case err == nil:
+			if existing.EmailVerifiedAt == nil {
+				return nil, ErrOIDCEmailNotVerified
+			}
+			user = existing
+		case !errors.Is(err, repositories.ErrUserNotFound):
+			return nil, err
+		}
+	}
+
+	if user == nil {
+		if user, err = s.createUser(ctx, idToken); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.identityRepo.Create(ctx, &models.ExternalIdentity{
+		Provider: provider,
+		Subject:  idToken.Subject,
+		UserID:   user.ID,
+		Email:    idToken.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// createUser registers a user for a new external identity. The account gets
+// a random password, so it can only sign in through the provider until the
+// user sets one with the password reset flow.
+func (s *oidcService) createUser(ctx context.Context, idToken *oidc.IDToken) (*models.User, error) {
+	username, err := s.availableUsername(ctx, idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := utils.HashPassword(utils.NewTokenID())
+	if err != nil {
+		return nil, errors.New("failed to process password")
+	}
+
+	user := &models.User{
+		Username:       username,
+		Name:           idToken.Name,
+		Role:           models.RoleUser,
+		HashedPassword: hashedPassword,
+	}
+	if user.Name == "" {
+		user.Name = username
+	}
+	// resolveUser has already checked that no account uses a verified
+	// address; unverified addresses are not claimed for the new account.
+	if idToken.EmailVerified {
+		user.Email = idToken.Email
+	}
+
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	if user.Email != "" {
+		if err := s.userRepo.MarkEmailVerified(ctx, user.ID, user.Email); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	return user, nil
+}
+
+func (s *oidcService) availableUsername(ctx context.Context, idToken *oidc.IDToken) (string, error) {
+	base := idToken.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(idToken.Email, "@")
+	}
+	base = sanitizeUsername(base)
+
+	for attempt := 0; attempt < 5; attempt++ {
+		candidate := base
+		if attempt > 0 {
+			candidate = base + "-" + utils.NewTokenID()[:6]
+		}
+		_, err := s.userRepo.FindByUsername(ctx, candidate)
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", errors.New("could not find an available username")
+}
+
+func sanitizeUsername(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '_' || r == '-' {
+			b.WriteRune(r)
+		}
+	}
+	username := b.String()
+	if len(username) > maxUsernameLength {
+		username = username[:maxUsernameLength]
+	}
+	if len(username) < 3 {
+		return "user"
+	}
+	return username
+}
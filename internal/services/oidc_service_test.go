@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/oidc"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockExternalIdentityRepo struct {
+	mock.Mock
+}
+
+func (m *MockExternalIdentityRepo) Find(ctx context.Context, provider string, subject string) (*models.ExternalIdentity, error) {
+	args := m.Called(ctx, provider, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ExternalIdentity), args.Error(1)
+}
+
+func (m *MockExternalIdentityRepo) Create(ctx context.Context, identity *models.ExternalIdentity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+func TestOIDCService_ResolveUser(t *testing.T) {
+	ctx := context.Background()
+	idToken := &oidc.IDToken{
+		RegisteredClaims:  jwt.RegisteredClaims{Subject: "subject-1"},
+		Email:             "budi@example.com",
+		EmailVerified:     true,
+		PreferredUsername: "Budi Santoso",
+	}
+
+	t.Run("identitas yang sudah terhubung memakai akun yang sama", func(t *testing.T) {
+		userRepo, identityRepo := new(MockUserRepo), new(MockExternalIdentityRepo)
+		service := &oidcService{userRepo: userRepo, identityRepo: identityRepo}
+
+		identityRepo.On("Find", ctx, "company", "subject-1").Return(&models.ExternalIdentity{UserID: "user-1"}, nil).Once()
+		userRepo.On("FindByID", ctx, "user-1").Return(&models.User{ID: "user-1"}, nil).Once()
+
+		user, err := service.resolveUser(ctx, "company", idToken)
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", user.ID)
+		identityRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("email terverifikasi dihubungkan ke akun yang sudah ada", func(t *testing.T) {
+		userRepo, identityRepo := new(MockUserRepo), new(MockExternalIdentityRepo)
+		service := &oidcService{userRepo: userRepo, identityRepo: identityRepo}
+
+		verifiedAt := time.Now()
+		identityRepo.On("Find", ctx, "company", "subject-1").Return(nil, repositories.ErrExternalIdentityNotFound).Once()
+		userRepo.On("FindByEmail", ctx, "budi@example.com").Return(&models.User{ID: "user-1", EmailVerifiedAt: &verifiedAt}, nil).Once()
+		identityRepo.On("Create", ctx, mock.MatchedBy(func(i *models.ExternalIdentity) bool {
+			return i.UserID == "user-1" && i.Subject == "subject-1"
+		})).Return(nil).Once()
+
+		user, err := service.resolveUser(ctx, "company", idToken)
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", user.ID)
+		identityRepo.AssertExpectations(t)
+	})
+
+	t.Run("akun dengan email yang belum diverifikasi tidak diambil alih", func(t *testing.T) {
+		userRepo, identityRepo := new(MockUserRepo), new(MockExternalIdentityRepo)
+		service := &oidcService{userRepo: userRepo, identityRepo: identityRepo}
+
+		identityRepo.On("Find", ctx, "company", "subject-1").Return(nil, repositories.ErrExternalIdentityNotFound).Once()
+		userRepo.On("FindByEmail", ctx, "budi@example.com").Return(&models.User{ID: "user-1"}, nil).Once()
+
+		_, err := service.resolveUser(ctx, "company", idToken)
+		assert.ErrorIs(t, err, ErrOIDCEmailNotVerified)
+		identityRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("identitas baru membuat akun baru", func(t *testing.T) {
+		userRepo, identityRepo := new(MockUserRepo), new(MockExternalIdentityRepo)
+		service := &oidcService{userRepo: userRepo, identityRepo: identityRepo}
+
+		identityRepo.On("Find", ctx, "company", "subject-1").Return(nil, repositories.ErrExternalIdentityNotFound).Once()
+		userRepo.On("FindByEmail", ctx, "budi@example.com").Return(nil, repositories.ErrUserNotFound).Once()
+		userRepo.On("FindByUsername", ctx, "budisantoso").Return(nil, repositories.ErrUserNotFound).Once()
+		userRepo.On("Create", ctx, mock.AnythingOfType("*models.User")).Return(nil).Once()
+		userRepo.On("MarkEmailVerified", ctx, mock.Anything, "budi@example.com").Return(nil).Once()
+		identityRepo.On("Create", ctx, mock.AnythingOfType("*models.ExternalIdentity")).Return(nil).Once()
+
+		user, err := service.resolveUser(ctx, "company", idToken)
+		require.NoError(t, err)
+		assert.Equal(t, "budisantoso", user.Username)
+		assert.Equal(t, "budi@example.com", user.Email)
+		assert.NotNil(t, user.EmailVerifiedAt)
+	})
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/mailer"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/revocation"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
+)
+
+const (
+	passwordResetTTL  = 30 * time.Minute
+	minPasswordLength = 8
+	// maxPasswordLength is the most bcrypt hashes; it refuses longer input.
+	maxPasswordLength = 72
+	mailSendTimeout   = 30 * time.Second
+
+	// passwordResetMailLimit caps the reset emails one account receives
+	// within passwordResetMailWindow, so the endpoint cannot be used to flood
+	// someone's inbox.
+	passwordResetMailLimit  = 3
+	passwordResetMailWindow = time.Hour
+)
+
+var (
+	ErrInvalidEmail     = errors.New("email address is not valid")
+	ErrPasswordTooShort = fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	ErrPasswordTooLong  = fmt.Errorf("password must be at most %d bytes", maxPasswordLength)
+)
+
+// validateNewPassword checks a password before it is hashed.
+func validateNewPassword(password string) error {
+	if len(password) < minPasswordLength {
+		return ErrPasswordTooShort
+	}
+	if len(password) > maxPasswordLength {
+		return ErrPasswordTooLong
+	}
+	return nil
+}
+
+type PasswordResetService interface {
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, req models.ResetPasswordRequest) error
+}
+
+type passwordResetService struct {
+	userRepo    repositories.UserRepository
+	resetRepo   repositories.PasswordResetRepository
+	sessionRepo repositories.SessionRepository
+	apiKeyRepo  repositories.APIKeyRepository
+	mailer      mailer.Mailer
+	revocations revocation.Store
+}
+
+func NewPasswordResetService(userRepo repositories.UserRepository, resetRepo repositories.PasswordResetRepository, sessionRepo repositories.SessionRepository, apiKeyRepo repositories.APIKeyRepository, m mailer.Mailer, revocations revocation.Store) PasswordResetService {
+	return &passwordResetService{
+		userRepo:    userRepo,
+		resetRepo:   resetRepo,
+		sessionRepo: sessionRepo,
+		apiKeyRepo:  apiKeyRepo,
+		mailer:      m,
+		revocations: revocations,
+	}
+}
+
+// ForgotPassword emails a reset token when the address belongs to a user who
+// has verified it; an unverified address may not be theirs. It
+// succeeds either way, and sends the mail in the background, so the response
+// reveals neither through its content nor its timing whether the address is
+// registered. Requests beyond passwordResetMailLimit per account are dropped
+// silently for the same reason.
+func (s *passwordResetService) ForgotPassword(ctx context.Context, email string) error {
+	if !utils.IsValidEmail(email) {
+		return ErrInvalidEmail
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt == nil {
+		return nil
+	}
+
+	sent, err := s.resetRepo.CountRecent(ctx, user.ID, time.Now().Add(-passwordResetMailWindow))
+	if err != nil {
+		return err
+	}
+	if sent >= passwordResetMailLimit {
+		log.Printf("SECURITY: dropping password reset email for user %s after %d requests", user.ID, sent)
+		return nil
+	}
+
+	token, hash := utils.NewSecretToken()
+	if err := s.resetRepo.Create(ctx, user.ID, hash, time.Now().Add(passwordResetTTL)); err != nil {
+		return err
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account %q. "+
+			"Send the token below to POST /auth/password/reset within %d minutes to choose a new password:\n\n%s\n\n"+
+			"If this was not you, you can ignore this email.\n",
+			user.Name, user.Username, int(passwordResetTTL.Minutes()), token),
+	}
+	go func() {
+		sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailSendTimeout)
+		defer cancel()
+		if err := s.mailer.Send(sendCtx, msg); err != nil {
+			log.Printf("Failed to send password reset email: %v", err)
+		}
+	}()
+	return nil
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword, signs
+// the user out everywhere and revokes their API keys, since a reset usually
+// means the old password is compromised. The token is looked up before the
+// password is hashed, so unknown tokens are turned away cheaply, and only
+// spent once the hash is ready, so a password that cannot be used does not
+// cost the user their token.
+func (s *passwordResetService) ResetPassword(ctx context.Context, req models.ResetPasswordRequest) error {
+	if err := validateNewPassword(req.Password); err != nil {
+		return err
+	}
+	tokenHash := utils.HashSecretToken(req.Token)
+	if _, err := s.resetRepo.Find(ctx, tokenHash); err != nil {
+		return err
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return errors.New("failed to process new password")
+	}
+	userID, err := s.resetRepo.Consume(ctx, tokenHash, hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := s.resetRepo.DeleteByUserID(ctx, userID); err != nil {
+		log.Printf("Failed to delete password reset tokens: %v", err)
+	}
+	if err := s.sessionRepo.DeleteByUserID(ctx, userID); err != nil {
+		log.Printf("Failed to end sessions after password reset: %v", err)
+	}
+	if err := s.apiKeyRepo.DeleteByUserID(ctx, userID); err != nil {
+		log.Printf("Failed to revoke API keys after password reset: %v", err)
+	}
+	if err := s.revocations.RevokeUser(ctx, userID, time.Now()); err != nil {
+		log.Printf("Failed to revoke tokens after password reset: %v", err)
+	}
+	return nil
+}
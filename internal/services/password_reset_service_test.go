@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/mailer"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/revocation"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockPasswordResetRepo struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetRepo) Create(ctx context.Context, userID string, tokenHash string, expiresAt time.Time) error {
+	args := m.Called(ctx, userID, tokenHash, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetRepo) Find(ctx context.Context, tokenHash string) (string, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPasswordResetRepo) Consume(ctx context.Context, tokenHash string, hashedPassword string) (string, error) {
+	args := m.Called(ctx, tokenHash, hashedPassword)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPasswordResetRepo) CountRecent(ctx context.Context, userID string, since time.Time) (int, error) {
+	args := m.Called(ctx, userID, since)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockPasswordResetRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+type chanMailer chan mailer.Message
+
+func (c chanMailer) Send(ctx context.Context, msg mailer.Message) error {
+	c <- msg
+	return nil
+}
+
+func TestPasswordResetService_ForgotPassword(t *testing.T) {
+	verifiedAt := time.Now()
+
+	t.Run("token dikirim ke email pengguna dan hanya hash yang disimpan", func(t *testing.T) {
+		userRepo, resetRepo := new(MockUserRepo), new(MockPasswordResetRepo)
+		mail := make(chanMailer, 1)
+		service := NewPasswordResetService(userRepo, resetRepo, nil, nil, mail, nil)
+
+		user := &models.User{ID: "user-1", Username: "budi", Email: "budi@example.com", EmailVerifiedAt: &verifiedAt}
+		userRepo.On("FindByEmail", mock.Anything, "budi@example.com").Return(user, nil).Once()
+		resetRepo.On("CountRecent", mock.Anything, "user-1", mock.AnythingOfType("time.Time")).Return(0, nil).Once()
+		resetRepo.On("Create", mock.Anything, "user-1", mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		require.NoError(t, service.ForgotPassword(context.Background(), "budi@example.com"))
+
+		msg := <-mail
+		assert.Equal(t, "budi@example.com", msg.To)
+		storedHash := resetRepo.Calls[1].Arguments.String(2)
+		found := false
+		for _, field := range strings.Fields(msg.Body) {
+			if utils.HashSecretToken(field) == storedHash {
+				found = true
+			}
+		}
+		assert.True(t, found, "email harus berisi token yang hash-nya disimpan")
+		assert.NotContains(t, msg.Body, storedHash)
+	})
+
+	t.Run("email yang tidak terdaftar tetap dianggap sukses", func(t *testing.T) {
+		userRepo, resetRepo := new(MockUserRepo), new(MockPasswordResetRepo)
+		service := NewPasswordResetService(userRepo, resetRepo, nil, nil, make(chanMailer, 1), nil)
+
+		userRepo.On("FindByEmail", mock.Anything, "siapa@example.com").Return(nil, repositories.ErrUserNotFound).Once()
+
+		assert.NoError(t, service.ForgotPassword(context.Background(), "siapa@example.com"))
+		resetRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("alamat yang belum diverifikasi tidak dikirimi token", func(t *testing.T) {
+		userRepo, resetRepo := new(MockUserRepo), new(MockPasswordResetRepo)
+		mail := make(chanMailer, 1)
+		service := NewPasswordResetService(userRepo, resetRepo, nil, nil, mail, nil)
+
+		user := &models.User{ID: "user-1", Username: "budi", Email: "budi@example.com"}
+		userRepo.On("FindByEmail", mock.Anything, "budi@example.com").Return(user, nil).Once()
+
+		assert.NoError(t, service.ForgotPassword(context.Background(), "budi@example.com"))
+		resetRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		assert.Empty(t, mail)
+	})
+
+	t.Run("permintaan berulang dibatasi per akun", func(t *testing.T) {
+		userRepo, resetRepo := new(MockUserRepo), new(MockPasswordResetRepo)
+		mail := make(chanMailer, passwordResetMailLimit+1)
+		service := NewPasswordResetService(userRepo, resetRepo, nil, nil, mail, nil)
+
+		user := &models.User{ID: "user-1", Username: "budi", Email: "budi@example.com", EmailVerifiedAt: &verifiedAt}
+		userRepo.On("FindByEmail", mock.Anything, "budi@example.com").Return(user, nil)
+		resetRepo.On("Create", mock.Anything, "user-1", mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil)
+		for i := 0; i <= passwordResetMailLimit; i++ {
+			resetRepo.On("CountRecent", mock.Anything, "user-1", mock.AnythingOfType("time.Time")).Return(i, nil).Once()
+		}
+
+		for i := 0; i < passwordResetMailLimit+1; i++ {
+			require.NoError(t, service.ForgotPassword(context.Background(), "budi@example.com"))
+		}
+
+		for i := 0; i < passwordResetMailLimit; i++ {
+			<-mail
+		}
+		resetRepo.AssertNumberOfCalls(t, "Create", passwordResetMailLimit)
+		assert.Empty(t, mail)
+	})
+}
+
+func TestPasswordResetService_ResetPassword(t *testing.T) {
+	t.Run("token yang tidak valid ditolak", func(t *testing.T) {
+		userRepo, resetRepo := new(MockUserRepo), new(MockPasswordResetRepo)
+		service := NewPasswordResetService(userRepo, resetRepo, nil, nil, nil, nil)
+
+		resetRepo.On("Find", mock.Anything, utils.HashSecretToken("bad-token")).Return("", repositories.ErrPasswordResetTokenInvalid).Once()
+
+		start := time.Now()
+		err := service.ResetPassword(context.Background(), models.ResetPasswordRequest{Token: "bad-token", Password: "new-password"})
+
+		assert.ErrorIs(t, err, repositories.ErrPasswordResetTokenInvalid)
+		assert.Less(t, time.Since(start), 100*time.Millisecond, "token yang tidak dikenal ditolak sebelum password di-hash")
+		resetRepo.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything, mock.Anything)
+		userRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("password terlalu pendek ditolak sebelum token dipakai", func(t *testing.T) {
+		resetRepo := new(MockPasswordResetRepo)
+		service := NewPasswordResetService(new(MockUserRepo), resetRepo, nil, nil, nil, nil)
+
+		err := service.ResetPassword(context.Background(), models.ResetPasswordRequest{Token: "token", Password: "short"})
+
+		assert.ErrorIs(t, err, ErrPasswordTooShort)
+		resetRepo.AssertNotCalled(t, "Find", mock.Anything, mock.Anything)
+	})
+
+	t.Run("password lebih dari 72 byte ditolak sebelum token dipakai", func(t *testing.T) {
+		resetRepo := new(MockPasswordResetRepo)
+		service := NewPasswordResetService(new(MockUserRepo), resetRepo, nil, nil, nil, nil)
+
+		err := service.ResetPassword(context.Background(), models.ResetPasswordRequest{Token: "token", Password: strings.Repeat("a", maxPasswordLength+1)})
+
+		assert.ErrorIs(t, err, ErrPasswordTooLong)
+		resetRepo.AssertNotCalled(t, "Find", mock.Anything, mock.Anything)
+	})
+
+	t.Run("password diganti, semua sesi diakhiri dan API key dicabut", func(t *testing.T) {
+		userRepo, resetRepo, sessionRepo, apiKeyRepo := new(MockUserRepo), new(MockPasswordResetRepo), new(MockSessionRepo), new(MockAPIKeyRepo)
+		revocations := revocation.NewMemoryStore(revocation.DefaultRetention)
+		service := NewPasswordResetService(userRepo, resetRepo, sessionRepo, apiKeyRepo, nil, revocations)
+
+		tokenHash := utils.HashSecretToken("good-token")
+		resetRepo.On("Find", mock.Anything, tokenHash).Return("user-1", nil).Once()
+		resetRepo.On("Consume", mock.Anything, tokenHash, mock.AnythingOfType("string")).Return("user-1", nil).Once()
+		resetRepo.On("DeleteByUserID", mock.Anything, "user-1").Return(nil).Once()
+		sessionRepo.On("DeleteByUserID", mock.Anything, "user-1").Return(nil).Once()
+		apiKeyRepo.On("DeleteByUserID", mock.Anything, "user-1").Return(nil).Once()
+		issuedBefore := time.Now().Add(-time.Minute)
+
+		err := service.ResetPassword(context.Background(), models.ResetPasswordRequest{Token: "good-token", Password: "new-password"})
+
+		require.NoError(t, err)
+		assert.True(t, utils.CheckPasswordHash("new-password", resetRepo.Calls[1].Arguments.String(2)))
+		revoked, err := revocations.IsRevoked(context.Background(), "user-1", issuedBefore)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		resetRepo.AssertExpectations(t)
+		sessionRepo.AssertExpectations(t)
+		apiKeyRepo.AssertExpectations(t)
+	})
+}
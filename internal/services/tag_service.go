@@ -0,0 +1,24 @@
+package services
+
+import (
+	"context"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+)
+
+type TagService interface {
+	GetTags(ctx context.Context) ([]models.Tag, error)
+}
+
+type tagService struct {
+	repo repositories.TagRepository
+}
+
+func NewTagService(repo repositories.TagRepository) TagService {
+	return &tagService{repo: repo}
+}
+
+func (s *tagService) GetTags(ctx context.Context) ([]models.Tag, error) {
+	return s.repo.FindAllWithCounts(ctx)
+}
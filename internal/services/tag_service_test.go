@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTagRepo struct {
+	mock.Mock
+}
+
+func (m *MockTagRepo) FindAllWithCounts(ctx context.Context) ([]models.Tag, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Tag), args.Error(1)
+}
+
+func TestTagService_GetTags(t *testing.T) {
+	t.Run("sukses mendapatkan tag beserta jumlah artikel", func(t *testing.T) {
+		repo := new(MockTagRepo)
+		service := NewTagService(repo)
+
+		expected := []models.Tag{{Name: "go", ArticleCount: 3}, {Name: "redis", ArticleCount: 1}}
+		repo.On("FindAllWithCounts", mock.Anything).Return(expected, nil).Once()
+
+		tags, err := service.GetTags(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, expected, tags)
+	})
+
+	t.Run("error dari repository diteruskan", func(t *testing.T) {
+		repo := new(MockTagRepo)
+		service := NewTagService(repo)
+		repo.On("FindAllWithCounts", mock.Anything).Return(nil, errors.New("db error")).Once()
+
+		_, err := service.GetTags(context.Background())
+		assert.Error(t, err)
+	})
+}
+
+func TestArticleService_Tags(t *testing.T) {
+	ctx := context.Background()
+	author := models.Actor{UserID: "user-1", Role: models.RoleUser}
+
+	t.Run("tag dinormalisasi saat artikel dibuat", func(t *testing.T) {
+		repo := new(MockArticleRepo)
+		service := NewArticleService(repo, cache.NewLRU(0), cache.NewLRU(0), nil, false, 0)
+		repo.On("SlugTaken", mock.Anything, "judul", "").Return(false, nil).Once()
+		repo.On("Create", mock.Anything, mock.AnythingOfType("*models.Article")).Return(nil).Once()
+
+		article, err := service.CreateArticle(ctx, models.CreateArticleRequest{Title: "Judul", Tags: []string{" Go ", "go", "", "Redis"}}, "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"go", "redis"}, article.Tags)
+	})
+
+	t.Run("tag yang terlalu panjang ditolak", func(t *testing.T) {
+		repo := new(MockArticleRepo)
+		service := NewArticleService(repo, cache.NewLRU(0), cache.NewLRU(0), nil, false, 0)
+		long := strings.Repeat("a", maxTagLength+1)
+
+		_, err := service.CreateArticle(ctx, models.CreateArticleRequest{Title: "Judul", Tags: []string{long}}, "user-1")
+		assert.ErrorIs(t, err, ErrInvalidTag)
+
+		repo.On("FindByID", mock.Anything, "article-1").Return(&models.Article{ID: "article-1", AuthorID: "user-1"}, nil).Once()
+		_, err = service.UpdateArticle(ctx, "article-1", models.UpdateArticleRequest{Tags: []string{"go", long}}, author)
+		assert.ErrorIs(t, err, ErrInvalidTag)
+
+		_, err = service.GetArticles(ctx, models.ListArticlesParams{Tags: []string{long}, Limit: 10})
+		assert.ErrorIs(t, err, ErrInvalidTag)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("batas panjang dihitung per karakter", func(t *testing.T) {
+		tags, err := normalizeTags([]string{strings.Repeat("é", maxTagLength)})
+		require.NoError(t, err)
+		assert.Len(t, tags, 1)
+	})
+}
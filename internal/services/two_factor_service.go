@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/totp"
+)
+
+const (
+	TwoFactorIssuer   = "Kumparan Article"
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+)
+
+type TwoFactorService interface {
+	Enroll(ctx context.Context, userID string) (*models.TwoFactorEnrollment, error)
+	Enable(ctx context.Context, userID string, code string) ([]string, error)
+	Disable(ctx context.Context, userID string, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID string, code string) ([]string, error)
+}
+
+type twoFactorService struct {
+	twoFactorRepo repositories.TwoFactorRepository
+	userRepo      repositories.UserRepository
+	loginAttempts repositories.LoginAttemptRepository
+}
+
+func NewTwoFactorService(twoFactorRepo repositories.TwoFactorRepository, userRepo repositories.UserRepository, loginAttempts repositories.LoginAttemptRepository) TwoFactorService {
+	return &twoFactorService{twoFactorRepo: twoFactorRepo, userRepo: userRepo, loginAttempts: loginAttempts}
+}
+
+func (s *twoFactorService) Enroll(ctx context.Context, userID string) (*models.TwoFactorEnrollment, error) {
+	current, err := s.twoFactorRepo.FindByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, repositories.ErrTwoFactorNotFound) {
+		return nil, err
+	}
+	if current != nil && current.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.SavePending(ctx, userID, secret); err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(TwoFactorIssuer, user.Username, secret),
+	}, nil
+}
+
+// Enable confirms a pending enrollment with a code from the authenticator app
+// and returns the recovery codes, which are only ever shown this once.
+func (s *twoFactorService) Enable(ctx context.Context, userID string, code string) ([]string, error) {
+	tf, err := s.twoFactorRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := totp.Validate(tf.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.Enable(ctx, userID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *twoFactorService) Disable(ctx context.Context, userID string, code string) error {
+	tf, err := s.findEnabled(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := verifyTwoFactorCode(ctx, s.twoFactorRepo, s.loginAttempts, tf, code); err != nil {
+		return err
+	}
+	return s.twoFactorRepo.Delete(ctx, userID)
+}
+
+func (s *twoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID string, code string) ([]string, error) {
+	tf, err := s.findEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyTwoFactorCode(ctx, s.twoFactorRepo, s.loginAttempts, tf, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *twoFactorService) findEnabled(ctx context.Context, userID string) (*models.TwoFactor, error) {
+	tf, err := s.twoFactorRepo.FindByUserID(ctx, userID)
+	if errors.Is(err, repositories.ErrTwoFactorNotFound) {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !tf.Enabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	return tf, nil
+}
+
+// verifyTwoFactorCode accepts either a current TOTP code or an unused recovery
+// code. Both are consumed on success so neither can be replayed. Wrong codes
+// count towards the user's two-factor lockout, which is checked first.
+func verifyTwoFactorCode(ctx context.Context, repo repositories.TwoFactorRepository, loginAttempts repositories.LoginAttemptRepository, tf *models.TwoFactor, code string) error {
+	attempts := []loginAttempt{{rule: twoFactorThrottle, value: tf.UserID}}
+	if err := checkLoginThrottle(ctx, loginAttempts, attempts); err != nil {
+		return err
+	}
+
+	used, err := useTwoFactorCode(ctx, repo, tf, code)
+	if err != nil {
+		return err
+	}
+	if !used {
+		recordLoginFailure(ctx, loginAttempts, attempts)
+		return ErrInvalidTwoFactorCode
+	}
+	if err := loginAttempts.Reset(ctx, twoFactorThrottle.key(tf.UserID)); err != nil {
+		log.Printf("Failed to reset two-factor throttle: %v", err)
+	}
+	return nil
+}
+
+func useTwoFactorCode(ctx context.Context, repo repositories.TwoFactorRepository, tf *models.TwoFactor, code string) (bool, error) {
+	if step, ok := totp.Validate(tf.Secret, code, time.Now()); ok {
+		return repo.UseStep(ctx, tf.UserID, step)
+	}
+	return repo.UseRecoveryCode(ctx, tf.UserID, hashRecoveryCode(code))
+}
+
+// generateRecoveryCodes returns codes formatted as xxxx-xxxx-xxxx-xxxx along
+// with their hashes. Each code carries 80 random bits, so a plain SHA-256 is
+// enough to store them safely without a slow password hash.
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(b))
+		codes[i] = raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12] + "-" + raw[12:16]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/cache"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTwoFactorRepo struct {
+	mock.Mock
+	repositories.TwoFactorRepository
+}
+
+func (m *MockTwoFactorRepo) FindByUserID(ctx context.Context, userID string) (*models.TwoFactor, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TwoFactor), args.Error(1)
+}
+
+func (m *MockTwoFactorRepo) Enable(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) error {
+	args := m.Called(ctx, userID, step, recoveryCodeHashes)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepo) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	args := m.Called(ctx, userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepo) UseRecoveryCode(ctx context.Context, userID string, codeHash string) (bool, error) {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepo) Delete(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func TestTwoFactorService_Enable(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	t.Run("kode valid mengaktifkan 2FA dan mengembalikan kode pemulihan", func(t *testing.T) {
+		mockRepo := new(MockTwoFactorRepo)
+		service := NewTwoFactorService(mockRepo, new(MockUserRepo), repositories.NewLoginAttemptRepo(cache.NewLRU(0)))
+
+		code, err := totp.Code(secret, totp.Step(time.Now()))
+		require.NoError(t, err)
+
+		mockRepo.On("FindByUserID", mock.Anything, "user-1").Return(&models.TwoFactor{UserID: "user-1", Secret: secret}, nil).Once()
+		mockRepo.On("Enable", mock.Anything, "user-1", mock.AnythingOfType("int64"), mock.AnythingOfType("[]string")).Return(nil).Once()
+
+		codes, err := service.Enable(context.Background(), "user-1", code)
+
+		assert.NoError(t, err)
+		assert.Len(t, codes, recoveryCodeCount)
+		hashes := mockRepo.Calls[1].Arguments.Get(3).([]string)
+		assert.Equal(t, hashRecoveryCode(codes[0]), hashes[0])
+		assert.NotEqual(t, codes[0], hashes[0])
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("kode salah ditolak", func(t *testing.T) {
+		mockRepo := new(MockTwoFactorRepo)
+		service := NewTwoFactorService(mockRepo, new(MockUserRepo), repositories.NewLoginAttemptRepo(cache.NewLRU(0)))
+
+		mockRepo.On("FindByUserID", mock.Anything, "user-1").Return(&models.TwoFactor{UserID: "user-1", Secret: secret}, nil).Once()
+
+		codes, err := service.Enable(context.Background(), "user-1", "000000x")
+
+		assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+		assert.Nil(t, codes)
+		mockRepo.AssertNotCalled(t, "Enable", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestTwoFactorService_Disable(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	tf := &models.TwoFactor{UserID: "user-1", Secret: secret, Enabled: true}
+
+	t.Run("kode pemulihan menonaktifkan 2FA", func(t *testing.T) {
+		mockRepo := new(MockTwoFactorRepo)
+		service := NewTwoFactorService(mockRepo, new(MockUserRepo), repositories.NewLoginAttemptRepo(cache.NewLRU(0)))
+
+		mockRepo.On("FindByUserID", mock.Anything, "user-1").Return(tf, nil).Once()
+		mockRepo.On("UseRecoveryCode", mock.Anything, "user-1", hashRecoveryCode("abcd-efgh-ijkl-mnop")).Return(true, nil).Once()
+		mockRepo.On("Delete", mock.Anything, "user-1").Return(nil).Once()
+
+		err := service.Disable(context.Background(), "user-1", "ABCD EFGH IJKL MNOP")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("kode TOTP yang sudah dipakai ditolak", func(t *testing.T) {
+		mockRepo := new(MockTwoFactorRepo)
+		service := NewTwoFactorService(mockRepo, new(MockUserRepo), repositories.NewLoginAttemptRepo(cache.NewLRU(0)))
+
+		step := totp.Step(time.Now())
+		code, err := totp.Code(secret, step)
+		require.NoError(t, err)
+
+		mockRepo.On("FindByUserID", mock.Anything, "user-1").Return(tf, nil).Once()
+		mockRepo.On("UseStep", mock.Anything, "user-1", mock.AnythingOfType("int64")).Return(false, nil).Once()
+
+		err = service.Disable(context.Background(), "user-1", code)
+
+		assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+	t.Run("kode salah berulang mengunci 2FA sebelum kode diperiksa", func(t *testing.T) {
+		mockRepo := new(MockTwoFactorRepo)
+		service := NewTwoFactorService(mockRepo, new(MockUserRepo), repositories.NewLoginAttemptRepo(cache.NewLRU(0)))
+
+		mockRepo.On("FindByUserID", mock.Anything, "user-1").Return(tf, nil)
+		mockRepo.On("UseRecoveryCode", mock.Anything, "user-1", mock.AnythingOfType("string")).Return(false, nil)
+
+		for i := int64(0); i <= twoFactorThrottle.freeAttempts; i++ {
+			assert.ErrorIs(t, service.Disable(context.Background(), "user-1", "tebakan"), ErrInvalidTwoFactorCode)
+		}
+		err := service.Disable(context.Background(), "user-1", "tebakan")
+
+		var throttled *TooManyAttemptsError
+		assert.ErrorAs(t, err, &throttled)
+		mockRepo.AssertNumberOfCalls(t, "UseRecoveryCode", int(twoFactorThrottle.freeAttempts)+1)
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
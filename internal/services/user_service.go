@@ -3,36 +3,64 @@ package services
 import (
 	"context"
 	"errors"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/internal/policy"
 	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/mailer"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/revocation"
 	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
 )
 
 var ErrUserAlreadyExists = errors.New("user already exists")
+var ErrEmailAlreadyExists = errors.New("email address is already in use")
 var (
-	ErrForbidden = errors.New("you do not have permission to perform this action")
+	ErrForbidden   = errors.New("you do not have permission to perform this action")
+	ErrInvalidRole = errors.New("role must be one of user, editor or admin")
 )
+var ErrIncorrectPassword = errors.New("current password is incorrect")
+var ErrPasswordNotUpdatable = errors.New("password cannot be changed here, use PUT /users/me/password")
 
 type UserService interface {
 	CreateUser(ctx context.Context, req models.CreateUserRequest) (*models.UserResponse, error)
 	GetUsers(ctx context.Context) ([]models.UserResponse, error)
 	GetUserByID(ctx context.Context, id string) (*models.UserResponse, error)
-	UpdateUser(ctx context.Context, id string, req models.UpdateUserRequest, currentUserID string) (*models.UserResponse, error)
-	DeleteUser(ctx context.Context, id string, currentUserID string) error
+	GetProfile(ctx context.Context, id string) (*models.UserResponse, error)
+	UpdateUser(ctx context.Context, id string, req models.UpdateUserRequest, actor models.Actor) (*models.UserResponse, error)
+	DeleteUser(ctx context.Context, id string, actor models.Actor) error
+	ChangePassword(ctx context.Context, id string, req models.ChangePasswordRequest) error
+	UpdateUserRole(ctx context.Context, id string, role string) (*models.UserResponse, error)
+	VerifyEmail(ctx context.Context, token string) error
 }
 
 type userService struct {
-	userRepo repositories.UserRepository
+	userRepo         repositories.UserRepository
+	verificationRepo repositories.EmailVerificationRepository
+	sessionRepo      repositories.SessionRepository
+	apiKeyRepo       repositories.APIKeyRepository
+	mailer           mailer.Mailer
+	revocations      revocation.Store
 }
 
-func NewUserService(userRepo repositories.UserRepository) UserService {
+func NewUserService(userRepo repositories.UserRepository, verificationRepo repositories.EmailVerificationRepository, sessionRepo repositories.SessionRepository, apiKeyRepo repositories.APIKeyRepository, m mailer.Mailer, revocations revocation.Store) UserService {
 	return &userService{
-		userRepo: userRepo,
+		userRepo:         userRepo,
+		verificationRepo: verificationRepo,
+		sessionRepo:      sessionRepo,
+		apiKeyRepo:       apiKeyRepo,
+		mailer:           m,
+		revocations:      revocations,
 	}
 }
 
 func (s *userService) CreateUser(ctx context.Context, req models.CreateUserRequest) (*models.UserResponse, error) {
+	if err := validateNewPassword(req.Password); err != nil {
+		return nil, err
+	}
+
 	_, err := s.userRepo.FindByUsername(ctx, req.Username)
 	if err == nil {
 		return nil, ErrUserAlreadyExists
@@ -41,6 +69,12 @@ func (s *userService) CreateUser(ctx context.Context, req models.CreateUserReque
 		return nil, err
 	}
 
+	if req.Email != "" {
+		if err := s.checkEmailAvailable(ctx, req.Email); err != nil {
+			return nil, err
+		}
+	}
+
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
 		return nil, errors.New("failed to process password")
@@ -49,19 +83,27 @@ func (s *userService) CreateUser(ctx context.Context, req models.CreateUserReque
 	user := &models.User{
 		Username:       req.Username,
 		Name:           req.Name,
+		Email:          req.Email,
+		Role:           models.RoleUser,
 		HashedPassword: hashedPassword,
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, err
 	}
+	if user.Email != "" {
+		s.sendVerificationEmail(ctx, user)
+	}
 
 	return &models.UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:            user.ID,
+		Username:      user.Username,
+		Name:          user.Name,
+		Email:         user.Email,
+		EmailVerified: emailVerified(user),
+		Role:          user.Role,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
 	}, nil
 }
 
@@ -77,6 +119,7 @@ func (s *userService) GetUsers(ctx context.Context) ([]models.UserResponse, erro
 			ID:        user.ID,
 			Username:  user.Username,
 			Name:      user.Name,
+			Role:      user.Role,
 			CreatedAt: user.CreatedAt,
 			UpdatedAt: user.UpdatedAt,
 		}
@@ -93,15 +136,38 @@ func (s *userService) GetUserByID(ctx context.Context, id string) (*models.UserR
 		ID:        user.ID,
 		Username:  user.Username,
 		Name:      user.Name,
+		Role:      user.Role,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
 	}, nil
 }
 
-func (s *userService) UpdateUser(ctx context.Context, id string, req models.UpdateUserRequest, currentUserID string) (*models.UserResponse, error) {
-	if id != currentUserID {
+// GetProfile returns a user as seen by the user themselves, including the
+// email address and its verification status that GetUserByID leaves out.
+func (s *userService) GetProfile(ctx context.Context, id string) (*models.UserResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &models.UserResponse{
+		ID:            user.ID,
+		Username:      user.Username,
+		Name:          user.Name,
+		Email:         user.Email,
+		EmailVerified: emailVerified(user),
+		Role:          user.Role,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}, nil
+}
+
+func (s *userService) UpdateUser(ctx context.Context, id string, req models.UpdateUserRequest, actor models.Actor) (*models.UserResponse, error) {
+	if !policy.CanManageUser(actor, id) {
 		return nil, ErrForbidden
 	}
+	if req.Password != nil {
+		return nil, ErrPasswordNotUpdatable
+	}
 
 	user, err := s.userRepo.FindByID(ctx, id)
 	if err != nil {
@@ -114,30 +180,130 @@ func (s *userService) UpdateUser(ctx context.Context, id string, req models.Upda
 	if req.Name != "" {
 		user.Name = req.Name
 	}
-	if req.Password != "" {
-		hashedPassword, err := utils.HashPassword(req.Password)
-		if err != nil {
-			return nil, errors.New("failed to process new password")
+	previousEmail := user.Email
+	emailChanged := req.Email != "" && !strings.EqualFold(req.Email, user.Email)
+	if emailChanged {
+		// Password resets go to this address, so a stolen session must not
+		// be enough to move the account to someone else's mailbox.
+		if actor.UserID == id && !utils.CheckPasswordHash(req.CurrentPassword, user.HashedPassword) {
+			return nil, ErrIncorrectPassword
 		}
-		user.HashedPassword = hashedPassword
+		if err := s.checkEmailAvailable(ctx, req.Email); err != nil {
+			return nil, err
+		}
+		user.Email = req.Email
+		user.EmailVerifiedAt = nil
 	}
-
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		return nil, err
 	}
+	if emailChanged {
+		s.sendVerificationEmail(ctx, user)
+		if previousEmail != "" {
+			s.sendEmailChangedNotice(ctx, previousEmail, user)
+		}
+	}
 
 	return &models.UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:            user.ID,
+		Username:      user.Username,
+		Name:          user.Name,
+		Email:         user.Email,
+		EmailVerified: emailVerified(user),
+		Role:          user.Role,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
 	}, nil
 }
 
-func (s *userService) DeleteUser(ctx context.Context, id string, currentUserID string) error {
-	if id != currentUserID {
+func (s *userService) DeleteUser(ctx context.Context, id string, actor models.Actor) error {
+	if !policy.CanManageUser(actor, id) {
 		return ErrForbidden
 	}
-	return s.userRepo.Delete(ctx, id)
+
+	if err := s.userRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.revocations.RevokeUser(ctx, id, time.Now()); err != nil {
+		log.Printf("Failed to revoke tokens of deleted user: %v", err)
+	}
+	return nil
+}
+
+// ChangePassword sets a new password after checking the current one, and
+// revokes every token and API key issued so far so other devices have to log
+// in again.
+func (s *userService) ChangePassword(ctx context.Context, id string, req models.ChangePasswordRequest) error {
+	if err := validateNewPassword(req.NewPassword); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !utils.CheckPasswordHash(req.CurrentPassword, user.HashedPassword) {
+		return ErrIncorrectPassword
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return errors.New("failed to process new password")
+	}
+	user.HashedPassword = hashedPassword
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	if err := s.apiKeyRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		log.Printf("Failed to revoke API keys after password change: %v", err)
+	}
+	if err := s.revocations.RevokeUser(ctx, user.ID, time.Now()); err != nil {
+		log.Printf("Failed to revoke tokens after password change: %v", err)
+	}
+	return nil
+}
+
+// UpdateUserRole changes the role of a user. Tokens carry the role they were
+// issued with, so a change signs the user out everywhere; otherwise a demoted
+// user would keep the old role until their access token expires.
+func (s *userService) UpdateUserRole(ctx context.Context, id string, role string) (*models.UserResponse, error) {
+	if !policy.IsValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+
+	user, err := s.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == role {
+		return s.GetUserByID(ctx, id)
+	}
+
+	if err := s.userRepo.UpdateRole(ctx, id, role); err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionRepo.DeleteByUserID(ctx, id); err != nil {
+		log.Printf("Failed to end sessions after role change: %v", err)
+	}
+	if err := s.revocations.RevokeUser(ctx, id, time.Now()); err != nil {
+		log.Printf("Failed to revoke tokens after role change: %v", err)
+	}
+	return s.GetUserByID(ctx, id)
+}
+
+func (s *userService) checkEmailAvailable(ctx context.Context, email string) error {
+	if !utils.IsValidEmail(email) {
+		return ErrInvalidEmail
+	}
+	_, err := s.userRepo.FindByEmail(ctx, email)
+	if err == nil {
+		return ErrEmailAlreadyExists
+	}
+	if !errors.Is(err, repositories.ErrUserNotFound) {
+		return err
+	}
+	return nil
 }
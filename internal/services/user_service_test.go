@@ -3,12 +3,17 @@ package services
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/dhifanrazaqa/kumparan-article/internal/models"
 	"github.com/dhifanrazaqa/kumparan-article/internal/repositories"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/revocation"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type MockUserRepo struct {
@@ -31,17 +36,55 @@ func (m *MockUserRepo) FindByUsername(ctx context.Context, username string) (*mo
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockUserRepo) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
 func (m *MockUserRepo) FindByID(ctx context.Context, id string) (*models.User, error) {
-	return nil, nil
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepo) FindAll(ctx context.Context) ([]models.User, error) { return nil, nil }
+func (m *MockUserRepo) Update(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+func (m *MockUserRepo) Delete(ctx context.Context, id string) error { return nil }
+func (m *MockUserRepo) UpdateRole(ctx context.Context, id string, role string) error {
+	args := m.Called(ctx, id, role)
+	return args.Error(0)
+}
+func (m *MockUserRepo) MarkEmailVerified(ctx context.Context, id string, email string) error {
+	args := m.Called(ctx, id, email)
+	return args.Error(0)
+}
+
+type MockEmailVerificationRepo struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerificationRepo) Create(ctx context.Context, userID string, email string, tokenHash string, expiresAt time.Time) error {
+	args := m.Called(ctx, userID, email, tokenHash, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationRepo) Consume(ctx context.Context, tokenHash string) (string, string, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.String(0), args.String(1), args.Error(2)
 }
-func (m *MockUserRepo) FindAll(ctx context.Context) ([]models.User, error)  { return nil, nil }
-func (m *MockUserRepo) Update(ctx context.Context, user *models.User) error { return nil }
-func (m *MockUserRepo) Delete(ctx context.Context, id string) error         { return nil }
 
 func TestUserService_CreateUser(t *testing.T) {
 	mockRepo := new(MockUserRepo)
 
-	userService := NewUserService(mockRepo)
+	userService := NewUserService(mockRepo, nil, nil, nil, nil, revocation.NewMemoryStore(revocation.DefaultRetention))
 
 	t.Run("sukses membuat pengguna baru", func(t *testing.T) {
 		mockRepo.On("FindByUsername", mock.Anything, "newuser").Return(nil, repositories.ErrUserNotFound).Once()
@@ -85,4 +128,173 @@ func TestUserService_CreateUser(t *testing.T) {
 
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("password di luar 8 sampai 72 byte ditolak sebelum di-hash", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		userService := NewUserService(mockRepo, nil, nil, nil, nil, nil)
+		for password, want := range map[string]error{
+			"pendek":                                 ErrPasswordTooShort,
+			strings.Repeat("a", maxPasswordLength+1): ErrPasswordTooLong,
+		} {
+			req := models.CreateUserRequest{Username: "newuser", Name: "New User", Password: password}
+			_, err := userService.CreateUser(context.Background(), req)
+			assert.ErrorIs(t, err, want)
+		}
+		mockRepo.AssertNotCalled(t, "FindByUsername", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUserService_EmailVerification(t *testing.T) {
+	hashedPassword, err := utils.HashPassword("password123")
+	require.NoError(t, err)
+
+	t.Run("mengganti email mereset status verifikasi dan mengirim token baru", func(t *testing.T) {
+		userRepo, verificationRepo := new(MockUserRepo), new(MockEmailVerificationRepo)
+		mail := make(chanMailer, 2)
+		service := NewUserService(userRepo, verificationRepo, nil, nil, mail, revocation.NewMemoryStore(revocation.DefaultRetention))
+
+		verifiedAt := time.Now()
+		user := &models.User{ID: "user-1", Username: "budi", Email: "budi@example.com", EmailVerifiedAt: &verifiedAt, HashedPassword: hashedPassword}
+		userRepo.On("FindByID", mock.Anything, "user-1").Return(user, nil).Once()
+		userRepo.On("FindByEmail", mock.Anything, "budi@new.example.com").Return(nil, repositories.ErrUserNotFound).Once()
+		userRepo.On("Update", mock.Anything, user).Return(nil).Once()
+		verificationRepo.On("Create", mock.Anything, "user-1", "budi@new.example.com", mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		actor := models.Actor{UserID: "user-1", Role: models.RoleUser}
+		resp, err := service.UpdateUser(context.Background(), "user-1", models.UpdateUserRequest{Email: "budi@new.example.com", CurrentPassword: "password123"}, actor)
+		assert.NoError(t, err)
+		assert.Nil(t, user.EmailVerifiedAt)
+		assert.False(t, *resp.EmailVerified)
+
+		sent := map[string]string{}
+		for i := 0; i < 2; i++ {
+			msg := <-mail
+			sent[msg.To] = msg.Body
+		}
+		require.Contains(t, sent, "budi@new.example.com")
+		storedHash := verificationRepo.Calls[0].Arguments.String(3)
+		assert.NotContains(t, sent["budi@new.example.com"], storedHash)
+		assert.Contains(t, sent["budi@example.com"], "budi@new.example.com", "alamat lama harus diberi tahu")
+	})
+
+	t.Run("mengganti email sendiri tanpa password yang benar ditolak", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		service := NewUserService(userRepo, new(MockEmailVerificationRepo), nil, nil, nil, nil)
+
+		user := &models.User{ID: "user-1", Username: "budi", Email: "budi@example.com", HashedPassword: hashedPassword}
+		userRepo.On("FindByID", mock.Anything, "user-1").Return(user, nil).Once()
+
+		actor := models.Actor{UserID: "user-1", Role: models.RoleUser}
+		_, err := service.UpdateUser(context.Background(), "user-1", models.UpdateUserRequest{Email: "penyerang@example.com", CurrentPassword: "salah"}, actor)
+
+		assert.ErrorIs(t, err, ErrIncorrectPassword)
+		assert.Equal(t, "budi@example.com", user.Email)
+		userRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("token yang valid memverifikasi alamat tujuan token", func(t *testing.T) {
+		userRepo, verificationRepo := new(MockUserRepo), new(MockEmailVerificationRepo)
+		service := NewUserService(userRepo, verificationRepo, nil, nil, nil, nil)
+
+		verificationRepo.On("Consume", mock.Anything, utils.HashSecretToken("token")).Return("user-1", "budi@example.com", nil).Once()
+		userRepo.On("MarkEmailVerified", mock.Anything, "user-1", "budi@example.com").Return(nil).Once()
+
+		assert.NoError(t, service.VerifyEmail(context.Background(), "token"))
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("token untuk alamat yang sudah diganti ditolak", func(t *testing.T) {
+		userRepo, verificationRepo := new(MockUserRepo), new(MockEmailVerificationRepo)
+		service := NewUserService(userRepo, verificationRepo, nil, nil, nil, nil)
+
+		verificationRepo.On("Consume", mock.Anything, utils.HashSecretToken("token")).Return("user-1", "lama@example.com", nil).Once()
+		userRepo.On("MarkEmailVerified", mock.Anything, "user-1", "lama@example.com").Return(repositories.ErrUserNotFound).Once()
+
+		err := service.VerifyEmail(context.Background(), "token")
+		assert.ErrorIs(t, err, repositories.ErrEmailVerificationTokenInvalid)
+	})
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	hashed, _ := utils.HashPassword("password-lama")
+
+	t.Run("password lama yang benar mengganti password dan mencabut token serta API key", func(t *testing.T) {
+		userRepo, apiKeyRepo := new(MockUserRepo), new(MockAPIKeyRepo)
+		revocations := revocation.NewMemoryStore(revocation.DefaultRetention)
+		service := NewUserService(userRepo, nil, nil, apiKeyRepo, nil, revocations)
+
+		user := &models.User{ID: "user-1", HashedPassword: hashed}
+		userRepo.On("FindByID", mock.Anything, "user-1").Return(user, nil).Once()
+		userRepo.On("Update", mock.Anything, user).Return(nil).Once()
+		apiKeyRepo.On("DeleteByUserID", mock.Anything, "user-1").Return(nil).Once()
+
+		issuedAt := time.Now().Add(-time.Minute)
+		req := models.ChangePasswordRequest{CurrentPassword: "password-lama", NewPassword: "password-baru"}
+		assert.NoError(t, service.ChangePassword(context.Background(), "user-1", req))
+		assert.True(t, utils.CheckPasswordHash("password-baru", user.HashedPassword))
+
+		revoked, err := revocations.IsRevoked(context.Background(), "user-1", issuedAt)
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+		apiKeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("password lama yang salah ditolak", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		service := NewUserService(userRepo, nil, nil, nil, nil, revocation.NewMemoryStore(revocation.DefaultRetention))
+
+		userRepo.On("FindByID", mock.Anything, "user-1").Return(&models.User{ID: "user-1", HashedPassword: hashed}, nil).Once()
+
+		req := models.ChangePasswordRequest{CurrentPassword: "salah", NewPassword: "password-baru"}
+		err := service.ChangePassword(context.Background(), "user-1", req)
+		assert.ErrorIs(t, err, ErrIncorrectPassword)
+		userRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("password baru lebih dari 72 byte ditolak", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		service := NewUserService(userRepo, nil, nil, nil, nil, nil)
+
+		req := models.ChangePasswordRequest{CurrentPassword: "password-lama", NewPassword: strings.Repeat("a", maxPasswordLength+1)}
+		err := service.ChangePassword(context.Background(), "user-1", req)
+		assert.ErrorIs(t, err, ErrPasswordTooLong)
+		userRepo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUserService_UpdateUserRole(t *testing.T) {
+	t.Run("perubahan role mengakhiri sesi dan mencabut token", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		sessionRepo := new(MockSessionRepo)
+		revocations := revocation.NewMemoryStore(revocation.DefaultRetention)
+		service := NewUserService(userRepo, nil, sessionRepo, nil, nil, revocations)
+
+		userRepo.On("FindByID", mock.Anything, "user-1").Return(&models.User{ID: "user-1", Role: models.RoleAdmin}, nil).Once()
+		userRepo.On("UpdateRole", mock.Anything, "user-1", models.RoleUser).Return(nil).Once()
+		sessionRepo.On("DeleteByUserID", mock.Anything, "user-1").Return(nil).Once()
+		userRepo.On("FindByID", mock.Anything, "user-1").Return(&models.User{ID: "user-1", Role: models.RoleUser}, nil).Once()
+
+		issuedAt := time.Now().Add(-time.Minute)
+		user, err := service.UpdateUserRole(context.Background(), "user-1", models.RoleUser)
+		assert.NoError(t, err)
+		assert.Equal(t, models.RoleUser, user.Role)
+		sessionRepo.AssertExpectations(t)
+
+		revoked, err := revocations.IsRevoked(context.Background(), "user-1", issuedAt)
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+	})
+
+	t.Run("role yang sama tidak mencabut apa pun", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		sessionRepo := new(MockSessionRepo)
+		service := NewUserService(userRepo, nil, sessionRepo, nil, nil, revocation.NewMemoryStore(revocation.DefaultRetention))
+
+		userRepo.On("FindByID", mock.Anything, "user-1").Return(&models.User{ID: "user-1", Role: models.RoleEditor}, nil)
+
+		_, err := service.UpdateUserRole(context.Background(), "user-1", models.RoleEditor)
+		assert.NoError(t, err)
+		userRepo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything, mock.Anything)
+		sessionRepo.AssertNotCalled(t, "DeleteByUserID", mock.Anything, mock.Anything)
+	})
 }
@@ -0,0 +1,60 @@
+// Package apikey generates and parses personal API keys of the form
+// "ak_<prefix>_<secret>". The prefix identifies a key in storage and in
+// listings; only a hash of the secret is ever stored.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const (
+	scheme       = "ak"
+	prefixBytes  = 4
+	secretBytes  = 32
+	prefixLength = prefixBytes * 2
+)
+
+var ErrInvalidKey = errors.New("API key is invalid or expired")
+
+// Generate returns a new key to show to its owner once, together with the
+// prefix and secret hash to store.
+func Generate() (key, prefix, hash string) {
+	p := make([]byte, prefixBytes)
+	rand.Read(p)
+	s := make([]byte, secretBytes)
+	rand.Read(s)
+
+	prefix = hex.EncodeToString(p)
+	secret := base64.RawURLEncoding.EncodeToString(s)
+	return scheme + "_" + prefix + "_" + secret, prefix, Hash(secret)
+}
+
+// Parse splits a key into its prefix and secret. The secret itself may
+// contain underscores.
+func Parse(key string) (prefix, secret string, err error) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != scheme || len(parts[1]) != prefixLength || parts[2] == "" {
+		return "", "", ErrInvalidKey
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return "", "", ErrInvalidKey
+	}
+	return parts[1], parts[2], nil
+}
+
+// Hash is a plain SHA-256: the secret has 256 bits of entropy, so a slow
+// password hash would only cost latency on every request.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func Verify(secret, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(Hash(secret)), []byte(hash)) == 1
+}
@@ -0,0 +1,34 @@
+package apikey
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAndParse(t *testing.T) {
+	t.Run("kunci yang dibuat bisa diurai dan diverifikasi", func(t *testing.T) {
+		key, prefix, hash := Generate()
+		assert.True(t, strings.HasPrefix(key, "ak_"+prefix+"_"))
+
+		parsedPrefix, secret, err := Parse(key)
+		require.NoError(t, err)
+		assert.Equal(t, prefix, parsedPrefix)
+		assert.True(t, Verify(secret, hash))
+		assert.NotContains(t, hash, secret)
+	})
+
+	t.Run("format yang salah ditolak", func(t *testing.T) {
+		for _, key := range []string{"", "ak_", "ak_1234abcd_", "xx_1234abcd_secret", "ak_zzzzzzzz_secret", "ak_123_secret"} {
+			_, _, err := Parse(key)
+			assert.ErrorIs(t, err, ErrInvalidKey, key)
+		}
+	})
+
+	t.Run("secret lain tidak cocok dengan hash", func(t *testing.T) {
+		_, _, hash := Generate()
+		assert.False(t, Verify("secret-lain", hash))
+	})
+}
@@ -0,0 +1,30 @@
+// Package cache provides the key-value store behind cached reads and
+// short-lived state such as login counters, with Redis, in-process and
+// two-tier implementations.
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("cache: key not found")
+
+// Cache is a key-value store with per-key expiry. A zero ttl stores a key
+// without expiry.
+type Cache interface {
+	// Get returns ErrNotFound when the key does not exist or has expired.
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	// Take returns the value of a key and deletes it atomically, so only one
+	// caller can obtain it.
+	Take(ctx context.Context, key string) ([]byte, error)
+	// Incr increments the integer stored at key, starting from zero, and
+	// returns the new value. A non-zero ttl restarts the key's expiry.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// TTL returns the remaining lifetime of a key, or zero when the key does
+	// not exist or never expires.
+	TTL(ctx context.Context, key string) (time.Duration, error)
+}
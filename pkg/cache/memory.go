@@ -0,0 +1,176 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// purgeInterval is how often Set sweeps out expired entries that were never
+// read again, so they do not pile up in an unbounded cache.
+const purgeInterval = time.Minute
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+type memoryCache struct {
+	mu        sync.Mutex
+	capacity  int
+	items     map[string]*list.Element
+	order     *list.List // most recently used at the front
+	lastPurge time.Time
+	now       func() time.Time
+}
+
+// NewLRU returns a process-local Cache that evicts the least recently used
+// key once it holds capacity keys. A capacity of zero never evicts, which is
+// what state such as login locks needs: evicting those would lift them.
+func NewLRU(capacity int) Cache {
+	return &memoryCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.lookup(key)
+	if entry == nil {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), entry.value...), nil
+}
+
+func (c *memoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.store(key, append([]byte(nil), value...), c.expiry(ttl))
+	return nil
+}
+
+func (c *memoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := c.items[key]; ok {
+			c.remove(elem)
+		}
+	}
+	return nil
+}
+
+func (c *memoryCache) Take(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.lookup(key)
+	if entry == nil {
+		return nil, ErrNotFound
+	}
+	c.remove(c.items[key])
+	return entry.value, nil
+}
+
+func (c *memoryCache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var n int64
+	var expiresAt time.Time
+	if entry := c.lookup(key); entry != nil {
+		var err error
+		if n, err = strconv.ParseInt(string(entry.value), 10, 64); err != nil {
+			return 0, err
+		}
+		expiresAt = entry.expiresAt
+	}
+	n++
+	if ttl > 0 {
+		expiresAt = c.expiry(ttl)
+	}
+	c.store(key, []byte(strconv.FormatInt(n, 10)), expiresAt)
+	return n, nil
+}
+
+func (c *memoryCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.lookup(key)
+	if entry == nil || entry.expiresAt.IsZero() {
+		return 0, nil
+	}
+	return entry.expiresAt.Sub(c.now()), nil
+}
+
+// lookup returns the live entry of key and marks it as recently used. It must
+// be called with c.mu held.
+func (c *memoryCache) lookup(key string) *memoryEntry {
+	elem, ok := c.items[key]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*memoryEntry)
+	if c.expired(entry) {
+		c.remove(elem)
+		return nil
+	}
+	c.order.MoveToFront(elem)
+	return entry
+}
+
+// store must be called with c.mu held.
+func (c *memoryCache) store(key string, value []byte, expiresAt time.Time) {
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*memoryEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		c.order.MoveToFront(elem)
+	} else {
+		c.items[key] = c.order.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+	}
+
+	if now := c.now(); now.Sub(c.lastPurge) >= purgeInterval {
+		c.lastPurge = now
+		c.purgeExpired()
+	}
+	for c.capacity > 0 && c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *memoryCache) purgeExpired() {
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if c.expired(elem.Value.(*memoryEntry)) {
+			c.remove(elem)
+		}
+		elem = next
+	}
+}
+
+func (c *memoryCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*memoryEntry).key)
+}
+
+func (c *memoryCache) expired(entry *memoryEntry) bool {
+	return !entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt)
+}
+
+func (c *memoryCache) expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return c.now().Add(ttl)
+}
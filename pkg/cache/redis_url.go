@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrInvalidRedisURL = errors.New("cache: invalid Redis URL")
+
+// NewRedisClient connects to the Redis deployment described by rawURL:
+//
+//	host:port                                    a single node, without TLS
+//	redis://[user:password@]host:port[/db]       a single node
+//	redis+sentinel://[user:password@]host:port[/db]?master=name
+//	redis+cluster://[user:password@]host:port
+//
+// The rediss, rediss+sentinel and rediss+cluster schemes connect over TLS.
+// Sentinel and cluster URLs list further nodes with repeated addr=host:port
+// parameters; sentinel_username and sentinel_password authenticate against
+// the sentinels when they differ from the data nodes. Commands honor the
+// deadline of their context.
+func NewRedisClient(rawURL string) (redis.UniversalClient, error) {
+	opts, mode, err := parseRedisURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch mode {
+	case "sentinel":
+		return redis.NewFailoverClient(opts.Failover()), nil
+	case "cluster":
+		return redis.NewClusterClient(opts.Cluster()), nil
+	default:
+		return redis.NewClient(opts.Simple()), nil
+	}
+}
+
+func parseRedisURL(rawURL string) (*redis.UniversalOptions, string, error) {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "redis://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidRedisURL, err)
+	}
+
+	scheme, mode, _ := strings.Cut(u.Scheme, "+")
+	if scheme != "redis" && scheme != "rediss" {
+		return nil, "", fmt.Errorf("%w: unsupported scheme %q", ErrInvalidRedisURL, u.Scheme)
+	}
+	if mode != "" && mode != "sentinel" && mode != "cluster" {
+		return nil, "", fmt.Errorf("%w: unsupported scheme %q", ErrInvalidRedisURL, u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, "", fmt.Errorf("%w: missing host", ErrInvalidRedisURL)
+	}
+
+	opts := &redis.UniversalOptions{
+		Addrs:                 []string{withDefaultPort(u.Host, mode)},
+		ContextTimeoutEnabled: true,
+	}
+	if u.User != nil {
+		opts.Username = u.User.Username()
+		opts.Password, _ = u.User.Password()
+	}
+
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if mode == "cluster" {
+			return nil, "", fmt.Errorf("%w: Redis Cluster has no database index", ErrInvalidRedisURL)
+		}
+		if opts.DB, err = strconv.Atoi(db); err != nil || opts.DB < 0 {
+			return nil, "", fmt.Errorf("%w: database index %q is not a number", ErrInvalidRedisURL, db)
+		}
+	}
+
+	query := u.Query()
+	for name, values := range query {
+		switch name {
+		case "addr":
+			if mode == "" {
+				return nil, "", fmt.Errorf("%w: addr needs a sentinel or cluster scheme", ErrInvalidRedisURL)
+			}
+			for _, addr := range values {
+				opts.Addrs = append(opts.Addrs, withDefaultPort(addr, mode))
+			}
+		case "master", "sentinel_username", "sentinel_password":
+			if mode != "sentinel" {
+				return nil, "", fmt.Errorf("%w: %s needs a sentinel scheme", ErrInvalidRedisURL, name)
+			}
+		default:
+			return nil, "", fmt.Errorf("%w: unknown parameter %q", ErrInvalidRedisURL, name)
+		}
+	}
+	if mode == "sentinel" {
+		opts.MasterName = query.Get("master")
+		opts.SentinelUsername = query.Get("sentinel_username")
+		opts.SentinelPassword = query.Get("sentinel_password")
+		if opts.MasterName == "" {
+			return nil, "", fmt.Errorf("%w: sentinel URLs need a master parameter", ErrInvalidRedisURL)
+		}
+	}
+
+	if scheme == "rediss" {
+		// Without a ServerName, each node's certificate is checked against the
+		// address it is dialed at, which also works for sentinel and cluster
+		// nodes learned at runtime.
+		opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return opts, mode, nil
+}
+
+func withDefaultPort(addr, mode string) string {
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return addr
+	}
+	host := strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+	if mode == "sentinel" {
+		return net.JoinHostPort(host, "26379")
+	}
+	return net.JoinHostPort(host, "6379")
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+type tieredCache struct {
+	local    Cache
+	remote   Cache
+	localTTL time.Duration
+}
+
+// NewTiered puts a local cache (L1) in front of a shared one (L2). Reads are
+// answered from L1 when possible and copied into it from L2 for at most
+// localTTL. Writes and deletes go to both tiers, but reach only this
+// instance's L1: other instances may serve a replaced value for up to
+// localTTL, so keep it short and use the tiered cache for hot, read-mostly
+// data. Take, Incr and TTL always go to L2.
+func NewTiered(local, remote Cache, localTTL time.Duration) Cache {
+	return &tieredCache{local: local, remote: remote, localTTL: localTTL}
+}
+
+func (c *tieredCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if val, err := c.local.Get(ctx, key); err == nil {
+		return val, nil
+	}
+
+	val, err := c.remote.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	c.local.Set(ctx, key, val, c.localTTL)
+	return val, nil
+}
+
+func (c *tieredCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := c.remote.Set(ctx, key, value, ttl); err != nil {
+		c.local.Delete(ctx, key)
+		return err
+	}
+
+	localTTL := c.localTTL
+	if ttl > 0 && ttl < localTTL {
+		localTTL = ttl
+	}
+	return c.local.Set(ctx, key, value, localTTL)
+}
+
+func (c *tieredCache) Delete(ctx context.Context, keys ...string) error {
+	c.local.Delete(ctx, keys...)
+	return c.remote.Delete(ctx, keys...)
+}
+
+func (c *tieredCache) Take(ctx context.Context, key string) ([]byte, error) {
+	c.local.Delete(ctx, key)
+	return c.remote.Take(ctx, key)
+}
+
+func (c *tieredCache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	c.local.Delete(ctx, key)
+	return c.remote.Incr(ctx, key, ttl)
+}
+
+func (c *tieredCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return c.remote.TTL(ctx, key)
+}
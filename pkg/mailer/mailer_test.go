@@ -0,0 +1,93 @@
+package mailer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer accepts a single session and returns the DATA it received.
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			switch strings.ToUpper(strings.SplitN(line, " ", 2)[0]) {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "DATA":
+				tp.PrintfLine("354 go ahead")
+				data, _ := tp.ReadDotBytes()
+				received <- string(data)
+				tp.PrintfLine("250 queued")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				return
+			default:
+				tp.PrintfLine("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	addr, received := fakeSMTPServer(t)
+	m, err := NewSMTPMailer(SMTPConfig{Addr: addr, From: "noreply@example.com"})
+	require.NoError(t, err)
+
+	err = m.Send(context.Background(), Message{To: "budi@example.com", Subject: "Reset password", Body: "token: abc"})
+
+	require.NoError(t, err)
+	data := <-received
+	assert.Contains(t, data, "To: budi@example.com")
+	assert.Contains(t, data, "Subject: Reset password")
+	assert.True(t, strings.HasSuffix(data, "token: abc\n"))
+}
+
+func TestLogMailer_Send(t *testing.T) {
+	t.Run("pesan ditulis ke writer", func(t *testing.T) {
+		var buf bytes.Buffer
+		m := NewLogMailer(&buf)
+
+		require.NoError(t, m.Send(context.Background(), Message{To: "budi@example.com", Subject: "Halo", Body: "isi"}))
+
+		scanner := bufio.NewScanner(&buf)
+		var lines []string
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		assert.Contains(t, lines, "To: budi@example.com")
+		assert.Contains(t, lines, "isi")
+	})
+
+	t.Run("header dengan baris baru ditolak", func(t *testing.T) {
+		m := NewLogMailer(&bytes.Buffer{})
+
+		err := m.Send(context.Background(), Message{To: "budi@example.com\r\nBcc: x@example.com", Subject: "Halo"})
+
+		assert.ErrorIs(t, err, ErrInvalidHeader)
+	})
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/apikey"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/revocation"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/signing"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
+)
+
+// APIKeyAuthenticator resolves a personal API key to the claims of its owner.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*models.Claims, error)
+}
+
+// Authenticate accepts "Authorization: ApiKey <key>" for machine clients in
+// addition to the bearer tokens accepted by JWT.
+func Authenticate(next http.Handler, verifier signing.Verifier, revocations revocation.Store, apiKeys APIKeyAuthenticator) http.Handler {
+	bearer := JWT(next, verifier, revocations)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, key, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !strings.EqualFold(scheme, "apikey") {
+			bearer.ServeHTTP(w, r)
+			return
+		}
+
+		claims, err := apiKeys.AuthenticateAPIKey(r.Context(), key)
+		if err != nil {
+			if errors.Is(err, apikey.ErrInvalidKey) {
+				utils.WriteError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+			log.Printf("Failed to check API key: %v", err)
+			utils.WriteError(w, http.StatusServiceUnavailable, "Unable to verify API key, please try again later")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), ClaimsContextKey, claims)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
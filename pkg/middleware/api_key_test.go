@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/apikey"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/signing"
+	"github.com/stretchr/testify/assert"
+)
+
+type apiKeyFunc func(ctx context.Context, key string) (*models.Claims, error)
+
+func (f apiKeyFunc) AuthenticateAPIKey(ctx context.Context, key string) (*models.Claims, error) {
+	return f(ctx, key)
+}
+
+func TestAuthenticate_APIKey(t *testing.T) {
+	apiKeys := apiKeyFunc(func(ctx context.Context, key string) (*models.Claims, error) {
+		switch key {
+		case "ak_valid":
+			return &models.Claims{UserID: "user-1"}, nil
+		case "ak_down":
+			return nil, errors.New("database down")
+		}
+		return nil, apikey.ErrInvalidKey
+	})
+
+	var gotUserID string
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserID = r.Context().Value(ClaimsContextKey).(*models.Claims).UserID
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := Authenticate(ok, signing.NewHMACKey([]byte("test-secret")), nil, apiKeys)
+
+	serve := func(authorization string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("Authorization", authorization)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	t.Run("API key valid diterima dengan claims pemiliknya", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("ApiKey ak_valid"))
+		assert.Equal(t, "user-1", gotUserID)
+	})
+
+	t.Run("API key tidak valid ditolak", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serve("ApiKey ak_salah"))
+	})
+
+	t.Run("gangguan penyimpanan tidak dianggap kunci salah", func(t *testing.T) {
+		assert.Equal(t, http.StatusServiceUnavailable, serve("ApiKey ak_down"))
+	})
+
+	t.Run("bearer token tetap diperiksa oleh JWT", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serve("Bearer bukan-token"))
+	})
+}
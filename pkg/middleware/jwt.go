@@ -2,10 +2,15 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/revocation"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/signing"
 	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -14,7 +19,12 @@ type contextKey string
 
 const ClaimsContextKey contextKey = "userClaims"
 
-func JWT(next http.Handler, secret string) http.Handler {
+var (
+	errTokenRevoked           = errors.New("token has been revoked")
+	errRevocationCheckFailure = errors.New("failed to check token revocation")
+)
+
+func JWT(next http.Handler, verifier signing.Verifier, revocations revocation.Store) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
@@ -29,16 +39,31 @@ func JWT(next http.Handler, secret string) http.Handler {
 		}
 		tokenString := parts[1]
 
-		claims := &models.Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(secret), nil
-		})
+		claims, err := verifyToken(r.Context(), tokenString, verifier, revocations)
+		if err != nil {
+			writeTokenError(w, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), ClaimsContextKey, claims)
 
-		if err != nil || !token.Valid {
-			utils.WriteError(w, http.StatusUnauthorized, "Token is invalid or expired")
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// OptionalJWT attaches the claims of a valid bearer token when one is present
+// but, unlike JWT, lets anonymous requests through.
+func OptionalJWT(next http.Handler, verifier signing.Verifier, revocations revocation.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(r.Header.Get("Authorization"), " ")
+		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		claims, err := verifyToken(r.Context(), parts[1], verifier, revocations)
+		if err != nil {
+			writeTokenError(w, err)
 			return
 		}
 
@@ -46,4 +71,52 @@ func JWT(next http.Handler, secret string) http.Handler {
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
-}
\ No newline at end of file
+}
+
+func verifyToken(ctx context.Context, tokenString string, verifier signing.Verifier, revocations revocation.Store) (*models.Claims, error) {
+	claims, err := parseClaims(tokenString, verifier)
+	if err != nil {
+		return nil, err
+	}
+	if revocations == nil {
+		return claims, nil
+	}
+
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	revoked, err := revocations.IsRevoked(ctx, claims.UserID, issuedAt, claims.ID, claims.SessionID)
+	if err != nil {
+		log.Printf("Failed to check token revocation: %v", err)
+		return nil, errRevocationCheckFailure
+	}
+	if revoked {
+		return nil, errTokenRevoked
+	}
+	return claims, nil
+}
+
+func writeTokenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errRevocationCheckFailure):
+		utils.WriteError(w, http.StatusServiceUnavailable, "Unable to verify token, please try again later")
+	case errors.Is(err, errTokenRevoked):
+		utils.WriteError(w, http.StatusUnauthorized, "Token has been revoked")
+	default:
+		utils.WriteError(w, http.StatusUnauthorized, "Token is invalid or expired")
+	}
+}
+
+func parseClaims(tokenString string, verifier signing.Verifier) (*models.Claims, error) {
+	claims := &models.Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, verifier.Keyfunc)
+
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/revocation"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/signing"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWT_Revocation(t *testing.T) {
+	key := signing.NewHMACKey([]byte("test-secret"))
+	refreshKey := signing.NewHMACKey([]byte("refresh"))
+	user := &models.User{ID: "user-1", Username: "tester", Role: models.RoleUser}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	serve := func(store revocation.Store, token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		JWT(ok, key, store).ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	t.Run("token valid diterima", func(t *testing.T) {
+		store := revocation.NewMemoryStore(time.Hour)
+		accessToken, _, err := utils.GenerateTokens(user, key, refreshKey, "sid", "jti", nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, serve(store, accessToken))
+	})
+
+	t.Run("token yang dicabut ditolak", func(t *testing.T) {
+		store := revocation.NewMemoryStore(time.Hour)
+		accessToken, _, err := utils.GenerateTokens(user, key, refreshKey, "sid", "jti", nil)
+		require.NoError(t, err)
+
+		claims, err := parseClaims(accessToken, key)
+		require.NoError(t, err)
+		require.NoError(t, store.RevokeToken(context.Background(), claims.ID, claims.ExpiresAt.Time))
+
+		assert.Equal(t, http.StatusUnauthorized, serve(store, accessToken))
+	})
+
+	t.Run("semua token user ditolak setelah user dicabut", func(t *testing.T) {
+		store := revocation.NewMemoryStore(time.Hour)
+		accessToken, _, err := utils.GenerateTokens(user, key, refreshKey, "sid", "jti", nil)
+		require.NoError(t, err)
+
+		require.NoError(t, store.RevokeUser(context.Background(), user.ID, time.Now().Add(time.Millisecond)))
+
+		assert.Equal(t, http.StatusUnauthorized, serve(store, accessToken))
+	})
+
+	t.Run("token yang terbit tepat setelah user dicabut diterima", func(t *testing.T) {
+		store := revocation.NewMemoryStore(time.Hour)
+		require.NoError(t, store.RevokeUser(context.Background(), user.ID, time.Now()))
+		time.Sleep(2 * time.Millisecond)
+
+		accessToken, _, err := utils.GenerateTokens(user, key, refreshKey, "sid", "jti", nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, serve(store, accessToken))
+	})
+}
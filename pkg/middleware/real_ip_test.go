@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRealIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.10"})
+	require.NoError(t, err)
+
+	clientIP := func(remoteAddr string, forwardedFor ...string) string {
+		var got string
+		handler := RealIP(proxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = utils.ClientIP(r)
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		for _, value := range forwardedFor {
+			req.Header.Add("X-Forwarded-For", value)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		return got
+	}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		want         string
+	}{
+		{"tanpa proxy memakai alamat koneksi", "203.0.113.5:4321", nil, "203.0.113.5"},
+		{"header dari klien langsung diabaikan", "203.0.113.5:4321", []string{"1.2.3.4"}, "203.0.113.5"},
+		{"proxy tepercaya meneruskan alamat klien", "10.0.0.2:80", []string{"203.0.113.5"}, "203.0.113.5"},
+		{"entri palsu di kiri diabaikan", "10.0.0.2:80", []string{"1.2.3.4, 203.0.113.5"}, "203.0.113.5"},
+		{"rantai proxy tepercaya dilewati", "10.0.0.2:80", []string{"203.0.113.5, 192.168.1.10"}, "203.0.113.5"},
+		{"beberapa header digabung", "10.0.0.2:80", []string{"1.2.3.4", "203.0.113.5"}, "203.0.113.5"},
+		{"entri yang bukan IP menghentikan pencarian", "10.0.0.2:80", []string{"bukan-ip"}, "10.0.0.2"},
+		{"proxy tepercaya tanpa header tetap dipakai", "10.0.0.2:80", nil, "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, clientIP(tt.remoteAddr, tt.forwardedFor...))
+		})
+	}
+
+	t.Run("alamat proxy yang tidak valid ditolak", func(t *testing.T) {
+		_, err := ParseTrustedProxies([]string{"10.0.0.0/99"})
+		assert.Error(t, err)
+		_, err = ParseTrustedProxies([]string{"proxy.local"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
+)
+
+// RequireRole rejects requests whose claims do not carry one of the given roles.
+// It must run after JWT.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value(ClaimsContextKey).(*models.Claims)
+			if !ok {
+				utils.WriteError(w, http.StatusUnauthorized, "Authorization header is required")
+				return
+			}
+			if !slices.Contains(roles, claims.Role) {
+				utils.WriteError(w, http.StatusForbidden, "You do not have permission to access this resource")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/utils"
+)
+
+// RequireScope rejects requests whose token or API key does not grant scope.
+// It must run after JWT or Authenticate.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value(ClaimsContextKey).(*models.Claims)
+			if !ok {
+				utils.WriteError(w, http.StatusUnauthorized, "Authorization header is required")
+				return
+			}
+			if !claims.HasScope(scope) {
+				utils.WriteError(w, http.StatusForbidden, "Token does not grant the "+scope+" scope")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireScope(t *testing.T) {
+	handler := RequireScope(models.ScopeArticlesWrite)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(claims *models.Claims) int {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req = req.WithContext(context.WithValue(req.Context(), ClaimsContextKey, claims))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	t.Run("token dengan scope yang dibutuhkan diterima", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(&models.Claims{Scope: "comments:write articles:write"}))
+	})
+
+	t.Run("token tanpa scope yang dibutuhkan ditolak", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve(&models.Claims{Scope: "comments:write"}))
+	})
+
+	t.Run("token lama tanpa klaim scope tetap diterima", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(&models.Claims{}))
+	})
+
+	t.Run("token baru tanpa scope hanya bisa membaca", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve(&models.Claims{ScopeVersion: models.ScopeClaimVersion}))
+	})
+}
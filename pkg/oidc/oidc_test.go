@@ -0,0 +1,152 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dhifanrazaqa/kumparan-article/pkg/signing"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockIdP is a minimal OpenID provider: /authorize immediately issues a code
+// for the configured subject, remembering the PKCE challenge and nonce.
+type mockIdP struct {
+	*httptest.Server
+	key       *signing.Key
+	challenge string
+	nonce     string
+	audience  string
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key, err := signing.NewRSAKey(private)
+	require.NoError(t, err)
+
+	idp := &mockIdP{key: key, audience: "client-1"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(idp.key.JWKS())
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		idp.challenge = r.URL.Query().Get("code_challenge")
+		idp.nonce = r.URL.Query().Get("nonce")
+		redirect := r.URL.Query().Get("redirect_uri") + "?code=code-1&state=" + url.QueryEscape(r.URL.Query().Get("state"))
+		http.Redirect(w, r, redirect, http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, secret, _ := r.BasicAuth()
+		if clientID != "client-1" || secret != "secret" || r.FormValue("code") != "code-1" || CodeChallenge(r.FormValue("code_verifier")) != idp.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		idToken, _ := idp.key.Sign(&IDToken{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    idp.URL,
+				Subject:   "subject-1",
+				Audience:  jwt.ClaimStrings{idp.audience},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+			Nonce:         idp.nonce,
+			Email:         "budi@example.com",
+			EmailVerified: true,
+		})
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "at", "token_type": "Bearer", "id_token": idToken})
+	})
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+// authorize follows the authorization URL and returns the code and state the
+// IdP sent back to the redirect URL.
+func authorize(t *testing.T, authURL string) (code, state string) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestProvider_Authenticate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("alur authorization code dengan PKCE berhasil", func(t *testing.T) {
+		idp := newMockIdP(t)
+		provider := NewProvider("company", Config{IssuerURL: idp.URL, ClientID: "client-1", ClientSecret: "secret", RedirectURL: "http://app/callback"}, nil)
+
+		verifier := NewCodeVerifier()
+		authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+		require.NoError(t, err)
+
+		code, state := authorize(t, authURL)
+		assert.Equal(t, "state-1", state)
+
+		idToken, err := provider.Authenticate(ctx, code, verifier, "nonce-1")
+		require.NoError(t, err)
+		assert.Equal(t, "subject-1", idToken.Subject)
+		assert.Equal(t, "budi@example.com", idToken.Email)
+		assert.True(t, idToken.EmailVerified)
+	})
+
+	t.Run("code verifier yang salah ditolak", func(t *testing.T) {
+		idp := newMockIdP(t)
+		provider := NewProvider("company", Config{IssuerURL: idp.URL, ClientID: "client-1", ClientSecret: "secret", RedirectURL: "http://app/callback"}, nil)
+
+		authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", NewCodeVerifier())
+		require.NoError(t, err)
+		code, _ := authorize(t, authURL)
+
+		_, err = provider.Authenticate(ctx, code, NewCodeVerifier(), "nonce-1")
+		assert.ErrorIs(t, err, ErrTokenExchange)
+	})
+
+	t.Run("nonce yang berbeda ditolak", func(t *testing.T) {
+		idp := newMockIdP(t)
+		provider := NewProvider("company", Config{IssuerURL: idp.URL, ClientID: "client-1", ClientSecret: "secret", RedirectURL: "http://app/callback"}, nil)
+
+		verifier := NewCodeVerifier()
+		authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+		require.NoError(t, err)
+		code, _ := authorize(t, authURL)
+
+		_, err = provider.Authenticate(ctx, code, verifier, "nonce-lain")
+		assert.ErrorIs(t, err, ErrInvalidIDToken)
+	})
+
+	t.Run("ID token untuk client lain ditolak", func(t *testing.T) {
+		idp := newMockIdP(t)
+		idp.audience = "client-lain"
+		provider := NewProvider("company", Config{IssuerURL: idp.URL, ClientID: "client-1", ClientSecret: "secret", RedirectURL: "http://app/callback"}, nil)
+
+		verifier := NewCodeVerifier()
+		authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+		require.NoError(t, err)
+		code, _ := authorize(t, authURL)
+
+		_, err = provider.Authenticate(ctx, code, verifier, "nonce-1")
+		assert.ErrorIs(t, err, ErrInvalidIDToken)
+	})
+}
@@ -0,0 +1,61 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryStore struct {
+	mu        sync.Mutex
+	retention time.Duration
+	tokens    map[string]time.Time
+	users     map[string]time.Time
+}
+
+// NewMemoryStore returns a process-local Store, intended for tests and
+// single-instance development setups.
+func NewMemoryStore(retention time.Duration) Store {
+	return &memoryStore{
+		retention: retention,
+		tokens:    make(map[string]time.Time),
+		users:     make(map[string]time.Time),
+	}
+}
+
+func (s *memoryStore) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[tokenID] = expiresAt
+	return nil
+}
+
+func (s *memoryStore) RevokeUser(ctx context.Context, userID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[userID] = at
+	return nil
+}
+
+func (s *memoryStore) IsRevoked(ctx context.Context, userID string, issuedAt time.Time, tokenIDs ...string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, tokenID := range tokenIDs {
+		if expiresAt, ok := s.tokens[tokenID]; ok {
+			if now.Before(expiresAt) {
+				return true, nil
+			}
+			delete(s.tokens, tokenID)
+		}
+	}
+
+	if revokedAt, ok := s.users[userID]; ok {
+		if now.Before(revokedAt.Add(s.retention)) {
+			return issuedBefore(issuedAt, revokedAt), nil
+		}
+		delete(s.users, userID)
+	}
+	return false, nil
+}
@@ -0,0 +1,50 @@
+package revocation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("token yang dicabut ditolak sampai kedaluwarsa", func(t *testing.T) {
+		store := NewMemoryStore(time.Hour)
+		assert.NoError(t, store.RevokeToken(ctx, "jti-1", now.Add(time.Minute)))
+		assert.NoError(t, store.RevokeToken(ctx, "jti-2", now.Add(-time.Minute)))
+
+		revoked, _ := store.IsRevoked(ctx, "user-1", now, "jti-1")
+		assert.True(t, revoked)
+		revoked, _ = store.IsRevoked(ctx, "user-1", now, "jti-2")
+		assert.False(t, revoked)
+		revoked, _ = store.IsRevoked(ctx, "user-1", now, "jti-3")
+		assert.False(t, revoked)
+	})
+
+	t.Run("mencabut user menolak token yang terbit sebelumnya", func(t *testing.T) {
+		store := NewMemoryStore(time.Hour)
+		assert.NoError(t, store.RevokeUser(ctx, "user-1", now))
+
+		revoked, _ := store.IsRevoked(ctx, "user-1", now.Add(-time.Minute), "old")
+		assert.True(t, revoked)
+		revoked, _ = store.IsRevoked(ctx, "user-1", now.Add(time.Minute), "new")
+		assert.False(t, revoked)
+		revoked, _ = store.IsRevoked(ctx, "user-2", now.Add(-time.Minute), "other")
+		assert.False(t, revoked)
+	})
+
+	t.Run("token yang terbit di detik yang sama setelah pencabutan diterima", func(t *testing.T) {
+		second := now.Truncate(time.Second)
+		store := NewMemoryStore(time.Hour)
+		assert.NoError(t, store.RevokeUser(ctx, "user-1", second.Add(300*time.Millisecond)))
+
+		revoked, _ := store.IsRevoked(ctx, "user-1", second.Add(100*time.Millisecond))
+		assert.True(t, revoked)
+		revoked, _ = store.IsRevoked(ctx, "user-1", second.Add(700*time.Millisecond))
+		assert.False(t, revoked)
+	})
+}
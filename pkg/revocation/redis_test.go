@@ -0,0 +1,44 @@
+package revocation
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisStore_RevokeUser(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	store := NewRedisStore(client, time.Hour)
+	second := time.Now().Truncate(time.Second)
+
+	t.Run("pencabutan disimpan dalam milidetik", func(t *testing.T) {
+		require.NoError(t, store.RevokeUser(ctx, "user-1", second.Add(300*time.Millisecond)))
+
+		revoked, err := store.IsRevoked(ctx, "user-1", second.Add(100*time.Millisecond))
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		revoked, err = store.IsRevoked(ctx, "user-1", second.Add(700*time.Millisecond))
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	})
+
+	t.Run("pencabutan lama dalam detik tetap berlaku", func(t *testing.T) {
+		require.NoError(t, mr.Set(userKey("user-2"), strconv.FormatInt(second.Unix(), 10)))
+
+		revoked, err := store.IsRevoked(ctx, "user-2", second.Add(-time.Minute))
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		revoked, err = store.IsRevoked(ctx, "user-2", second.Add(time.Minute))
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	})
+}
@@ -0,0 +1,26 @@
+package revocation
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps track of revoked JWTs. Single tokens are denied by an identifier
+// such as their "jti" or "sid" claim, while revoking a user denies every token
+// issued to them up to that moment.
+type Store interface {
+	RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+	RevokeUser(ctx context.Context, userID string, at time.Time) error
+	IsRevoked(ctx context.Context, userID string, issuedAt time.Time, tokenIDs ...string) (bool, error)
+}
+
+// DefaultRetention covers the lifetime of the longest-lived token the API
+// issues, so a user-wide revocation outlives every token it applies to.
+const DefaultRetention = 7 * 24 * time.Hour
+
+// issuedBefore compares at the millisecond precision of the iat claim. A
+// token issued in the same millisecond as the revocation is kept, so the user
+// can log in again right away.
+func issuedBefore(issuedAt, revokedAt time.Time) bool {
+	return issuedAt.UnixMilli() < revokedAt.UnixMilli()
+}
@@ -0,0 +1,162 @@
+package signing
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrRetireCurrentKey = errors.New("cannot retire the current signing key")
+
+// Keyring signs with one current key and verifies with every key that has not
+// been retired yet, so rotating the signing key does not invalidate tokens
+// that are already issued.
+type Keyring struct {
+	mu      sync.RWMutex
+	current *Key
+	entries []keyringEntry
+}
+
+type keyringEntry struct {
+	key      *Key
+	retireAt time.Time
+}
+
+func (e keyringEntry) active(now time.Time) bool {
+	return e.retireAt.IsZero() || now.Before(e.retireAt)
+}
+
+func NewKeyring(current *Key) *Keyring {
+	return &Keyring{
+		current: current,
+		entries: []keyringEntry{{key: current}},
+	}
+}
+
+// Add registers a verification-only key. It stops being accepted at retireAt,
+// or never when retireAt is zero.
+func (r *Keyring) Add(key *Key, retireAt time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if key.ID == r.current.ID {
+		return
+	}
+	r.remove(key.ID)
+	r.entries = append(r.entries, keyringEntry{key: key, retireAt: retireAt})
+}
+
+// Rotate makes next the signing key. The previous key keeps verifying for
+// grace, which should be at least the lifetime of the tokens it signed.
+func (r *Keyring) Rotate(next *Key, grace time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	previous := r.current
+	r.prune(now)
+	r.remove(next.ID)
+	for i := range r.entries {
+		if r.entries[i].key.ID == previous.ID {
+			r.entries[i].retireAt = now.Add(grace)
+		}
+	}
+	r.entries = append([]keyringEntry{{key: next}}, r.entries...)
+	r.current = next
+}
+
+// Retire stops accepting tokens signed with the key kid from at onwards.
+func (r *Keyring) Retire(kid string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if kid == r.current.ID {
+		return ErrRetireCurrentKey
+	}
+	for i := range r.entries {
+		if r.entries[i].key.ID == kid {
+			r.entries[i].retireAt = at
+			return nil
+		}
+	}
+	return ErrUnknownKey
+}
+
+func (r *Keyring) Current() *Key {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current
+}
+
+func (r *Keyring) Sign(claims jwt.Claims) (string, error) {
+	return r.Current().Sign(claims)
+}
+
+// Keyfunc picks the verification key named by the token's "kid" header.
+// Tokens without one are checked against every active key of the same
+// algorithm.
+func (r *Keyring) Keyfunc(token *jwt.Token) (interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	if raw, ok := token.Header["kid"]; ok {
+		kid, _ := raw.(string)
+		for _, entry := range r.entries {
+			if entry.key.ID == kid && entry.active(now) {
+				return entry.key.Keyfunc(token)
+			}
+		}
+		return nil, ErrUnknownKey
+	}
+
+	var set jwt.VerificationKeySet
+	for _, entry := range r.entries {
+		if entry.active(now) && entry.key.Algorithm() == token.Method.Alg() {
+			set.Keys = append(set.Keys, entry.key.verifyKey)
+		}
+	}
+	if len(set.Keys) == 0 {
+		return nil, ErrUnknownKey
+	}
+	return set, nil
+}
+
+func (r *Keyring) JWKS() JWKS {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	keys := []JWK{}
+	for _, entry := range r.entries {
+		if !entry.active(now) {
+			continue
+		}
+		if jwk, ok := entry.key.JWK(); ok {
+			keys = append(keys, jwk)
+		}
+	}
+	return JWKS{Keys: keys}
+}
+
+func (r *Keyring) remove(kid string) {
+	entries := r.entries[:0]
+	for _, entry := range r.entries {
+		if entry.key.ID != kid {
+			entries = append(entries, entry)
+		}
+	}
+	r.entries = entries
+}
+
+func (r *Keyring) prune(now time.Time) {
+	entries := r.entries[:0]
+	for _, entry := range r.entries {
+		if entry.active(now) {
+			entries = append(entries, entry)
+		}
+	}
+	r.entries = entries
+}
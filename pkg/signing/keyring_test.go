@@ -0,0 +1,74 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEd25519Key(t *testing.T) *Key {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := NewEd25519Key(private)
+	require.NoError(t, err)
+	return key
+}
+
+func verify(keys *Keyring, tokenString string) error {
+	_, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, keys.Keyfunc)
+	return err
+}
+
+func TestKeyring(t *testing.T) {
+	t.Run("token lama tetap valid setelah rotasi", func(t *testing.T) {
+		oldKey, newKey := newEd25519Key(t), newEd25519Key(t)
+		keys := NewKeyring(oldKey)
+		oldToken, err := keys.Sign(newClaims())
+		require.NoError(t, err)
+
+		keys.Rotate(newKey, time.Hour)
+		newToken, err := keys.Sign(newClaims())
+		require.NoError(t, err)
+
+		assert.NoError(t, verify(keys, oldToken))
+		assert.NoError(t, verify(keys, newToken))
+		assert.Equal(t, newKey.ID, keys.Current().ID)
+		assert.Len(t, keys.JWKS().Keys, 2)
+	})
+
+	t.Run("token dari kunci yang sudah dipensiunkan ditolak", func(t *testing.T) {
+		oldKey, newKey := newEd25519Key(t), newEd25519Key(t)
+		keys := NewKeyring(oldKey)
+		oldToken, err := keys.Sign(newClaims())
+		require.NoError(t, err)
+
+		keys.Rotate(newKey, time.Hour)
+		require.NoError(t, keys.Retire(oldKey.ID, time.Now()))
+
+		assert.ErrorIs(t, verify(keys, oldToken), ErrUnknownKey)
+		assert.Len(t, keys.JWKS().Keys, 1)
+	})
+
+	t.Run("kunci yang sedang dipakai tidak bisa dipensiunkan", func(t *testing.T) {
+		current := newEd25519Key(t)
+		keys := NewKeyring(current)
+
+		assert.ErrorIs(t, keys.Retire(current.ID, time.Now()), ErrRetireCurrentKey)
+	})
+
+	t.Run("token tanpa kid diverifikasi dengan semua kunci aktif", func(t *testing.T) {
+		previous := []byte("previous-secret")
+		keys := NewKeyring(NewHMACKey([]byte("current-secret")))
+		keys.Add(NewHMACKey(previous), time.Now().Add(time.Hour))
+
+		legacyToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims()).SignedString(previous)
+		require.NoError(t, err)
+
+		assert.NoError(t, verify(keys, legacyToken))
+	})
+}
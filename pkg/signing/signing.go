@@ -0,0 +1,117 @@
+// Package signing signs and verifies JWTs with HMAC, RSA (RS256) or Ed25519
+// (EdDSA) keys and publishes the public half of asymmetric keys as a JWKS.
+package signing
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnknownKey       = errors.New("unknown signing key")
+	ErrUnexpectedMethod = errors.New("unexpected signing method")
+)
+
+type Signer interface {
+	Sign(claims jwt.Claims) (string, error)
+}
+
+type Verifier interface {
+	Keyfunc(token *jwt.Token) (interface{}, error)
+}
+
+type SignerVerifier interface {
+	Signer
+	Verifier
+}
+
+// KeySet exposes the public keys that third parties may use to verify tokens.
+type KeySet interface {
+	JWKS() JWKS
+}
+
+// Key is a single signing key identified by the "kid" header of the tokens it
+// signs.
+type Key struct {
+	ID        string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+func NewHMACKey(secret []byte) *Key {
+	return &Key{
+		ID:        keyID(secret),
+		method:    jwt.SigningMethodHS256,
+		signKey:   secret,
+		verifyKey: secret,
+	}
+}
+
+func NewRSAKey(private *rsa.PrivateKey) (*Key, error) {
+	return newAsymmetricKey(jwt.SigningMethodRS256, private, &private.PublicKey)
+}
+
+func NewEd25519Key(private ed25519.PrivateKey) (*Key, error) {
+	return newAsymmetricKey(jwt.SigningMethodEdDSA, private, private.Public())
+}
+
+func newAsymmetricKey(method jwt.SigningMethod, private crypto.Signer, public crypto.PublicKey) (*Key, error) {
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{
+		ID:        keyID(der),
+		method:    method,
+		signKey:   private,
+		verifyKey: public,
+	}, nil
+}
+
+func (k *Key) Algorithm() string {
+	return k.method.Alg()
+}
+
+func (k *Key) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.ID
+	return token.SignedString(k.signKey)
+}
+
+// Keyfunc resolves the verification key for token. The algorithm must match
+// the key exactly so an RSA public key can never be used as an HMAC secret.
+// Tokens without a "kid" header, issued before keys had IDs, are still
+// checked against the key.
+func (k *Key) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if kid, ok := token.Header["kid"]; ok && kid != k.ID {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, fmt.Errorf("%w: %s", ErrUnexpectedMethod, token.Method.Alg())
+	}
+	return k.verifyKey, nil
+}
+
+func (k *Key) JWKS() JWKS {
+	keys := []JWK{}
+	if jwk, ok := k.JWK(); ok {
+		keys = append(keys, jwk)
+	}
+	return JWKS{Keys: keys}
+}
+
+// keyID derives a stable identifier from the key material so that the same
+// key always gets the same "kid" without any extra configuration.
+func keyID(material []byte) string {
+	sum := sha256.Sum256(material)
+	return hex.EncodeToString(sum[:8])
+}
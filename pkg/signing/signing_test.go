@@ -0,0 +1,105 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePKCS8(t *testing.T, private interface{}) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func newClaims() *jwt.RegisteredClaims {
+	return &jwt.RegisteredClaims{
+		Subject:   "user-1",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}
+}
+
+func TestKey_SignAndVerify(t *testing.T) {
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		pem  []byte
+		alg  string
+		kty  string
+	}{
+		{name: "kunci RSA menghasilkan RS256", pem: encodePKCS8(t, rsaPrivate), alg: "RS256", kty: "RSA"},
+		{name: "kunci RSA PKCS1 menghasilkan RS256", pem: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaPrivate)}), alg: "RS256", kty: "RSA"},
+		{name: "kunci Ed25519 menghasilkan EdDSA", pem: encodePKCS8(t, edPrivate), alg: "EdDSA", kty: "OKP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParsePrivateKeyPEM(tt.pem)
+			require.NoError(t, err)
+			assert.Equal(t, tt.alg, key.Algorithm())
+
+			tokenString, err := key.Sign(newClaims())
+			require.NoError(t, err)
+
+			token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, key.Keyfunc)
+			require.NoError(t, err)
+			assert.Equal(t, key.ID, token.Header["kid"])
+
+			jwks := key.JWKS()
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, tt.kty, jwks.Keys[0].KeyType)
+			assert.Equal(t, key.ID, jwks.Keys[0].KeyID)
+
+			public, err := jwks.Keys[0].PublicKey()
+			require.NoError(t, err)
+			_, err = jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) { return public, nil })
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestKey_Keyfunc(t *testing.T) {
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key, err := NewRSAKey(rsaPrivate)
+	require.NoError(t, err)
+
+	t.Run("token HS256 yang ditandatangani dengan kunci publik ditolak", func(t *testing.T) {
+		publicDER, err := x509.MarshalPKIXPublicKey(&rsaPrivate.PublicKey)
+		require.NoError(t, err)
+		forged := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims())
+		forged.Header["kid"] = key.ID
+		tokenString, err := forged.SignedString(publicDER)
+		require.NoError(t, err)
+
+		_, err = jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, key.Keyfunc)
+		assert.ErrorIs(t, err, ErrUnexpectedMethod)
+	})
+
+	t.Run("kid yang tidak dikenal ditolak", func(t *testing.T) {
+		other, err := NewRSAKey(rsaPrivate)
+		require.NoError(t, err)
+		other.ID = "other"
+		tokenString, err := other.Sign(newClaims())
+		require.NoError(t, err)
+
+		_, err = jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, key.Keyfunc)
+		assert.ErrorIs(t, err, ErrUnknownKey)
+	})
+
+	t.Run("kunci HMAC tidak dipublikasikan", func(t *testing.T) {
+		assert.Empty(t, NewHMACKey([]byte("secret")).JWKS().Keys)
+	})
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffLines(t *testing.T) {
+	t.Run("teks sama menghasilkan baris equal", func(t *testing.T) {
+		diff := DiffLines("a\nb", "a\nb")
+		assert.Equal(t, []models.DiffLine{
+			{Op: DiffEqual, Text: "a"},
+			{Op: DiffEqual, Text: "b"},
+		}, diff)
+	})
+
+	t.Run("baris diubah, ditambah, dan dihapus", func(t *testing.T) {
+		diff := DiffLines("a\nb\nc", "a\nx\nc\nd")
+		assert.Equal(t, []models.DiffLine{
+			{Op: DiffEqual, Text: "a"},
+			{Op: DiffDelete, Text: "b"},
+			{Op: DiffInsert, Text: "x"},
+			{Op: DiffEqual, Text: "c"},
+			{Op: DiffInsert, Text: "d"},
+		}, diff)
+	})
+
+	t.Run("teks kosong", func(t *testing.T) {
+		assert.Equal(t, []models.DiffLine{{Op: DiffInsert, Text: "a"}}, DiffLines("", "a"))
+		assert.Empty(t, DiffLines("", ""))
+	})
+}
@@ -0,0 +1,10 @@
+package utils
+
+import "net/mail"
+
+// IsValidEmail reports whether email is a bare address such as
+// "budi@example.com", rejecting display names and angle brackets.
+func IsValidEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const maxSlugLength = 80
+
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'Æ': "ae", 'ø': "o", 'Ø': "o", 'œ': "oe", 'Œ': "oe",
+	'đ': "d", 'Đ': "d", 'ł': "l", 'Ł': "l", 'þ': "th", 'Þ': "th", 'ð': "d", 'Ð': "d",
+	'ı': "i", '&': "and",
+}
+
+// Slugify turns a title into a lowercase, hyphen-separated ASCII slug.
+// Accented Latin letters are reduced to their base letter; characters with no
+// ASCII equivalent are dropped.
+func Slugify(title string) string {
+	var b strings.Builder
+	pendingHyphen := false
+
+	for _, r := range norm.NFKD.String(title) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+
+		var chunk string
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			chunk = string(unicode.ToLower(r))
+		case transliterations[r] != "":
+			chunk = transliterations[r]
+		case r < unicode.MaxASCII || unicode.IsSpace(r) || unicode.IsPunct(r):
+			pendingHyphen = b.Len() > 0
+			continue
+		default:
+			continue
+		}
+
+		if pendingHyphen {
+			b.WriteByte('-')
+			pendingHyphen = false
+		}
+		b.WriteString(chunk)
+	}
+
+	slug := b.String()
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+		if i := strings.LastIndexByte(slug, '-'); i > 0 {
+			slug = slug[:i]
+		}
+	}
+	if slug == "" {
+		return "article"
+	}
+	return slug
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"Hello World":                "hello-world",
+		"  Go 1.24 -- Released!  ":   "go-1-24-released",
+		"Café Crème à la Mode":       "cafe-creme-a-la-mode",
+		"Straße & Smørrebrød":        "strasse-and-smorrebrod",
+		"日本語":                        "article",
+		"Berita: Jakarta — Hari Ini": "berita-jakarta-hari-ini",
+	}
+	for title, want := range cases {
+		assert.Equal(t, want, Slugify(title), title)
+	}
+
+	t.Run("slug panjang dipotong di batas kata", func(t *testing.T) {
+		slug := Slugify(strings.Repeat("word ", 40))
+		assert.LessOrEqual(t, len(slug), maxSlugLength)
+		assert.False(t, strings.HasSuffix(slug, "-"))
+	})
+}
@@ -1,41 +1,82 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
 	"time"
 
 	"github.com/dhifanrazaqa/kumparan-article/internal/models"
+	"github.com/dhifanrazaqa/kumparan-article/pkg/signing"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func GenerateTokens(user *models.User, accessTokenSecret string, refreshTokenSecret string) (string, string, error) {
+const (
+	AccessTokenTTL  = 1 * time.Hour
+	RefreshTokenTTL = 7 * 24 * time.Hour
+)
+
+func init() {
+	// Issue and read iat and exp with millisecond precision, so a token
+	// issued right after a user-wide revocation is not mistaken for one
+	// issued before it within the same second.
+	jwt.TimePrecision = time.Millisecond
+}
+
+func GenerateTokens(user *models.User, accessTokenSigner signing.Signer, refreshTokenSigner signing.Signer, sessionID string, refreshTokenID string, scopes []string) (string, string, error) {
 	accessTokenClaims := &models.Claims{
-		UserID:   user.ID,
-		Username: user.Username,
+		UserID:       user.ID,
+		Username:     user.Username,
+		Role:         user.Role,
+		SessionID:    sessionID,
+		Scope:        strings.Join(scopes, " "),
+		ScopeVersion: models.ScopeClaimVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(1 * time.Hour)),
-			IssuedAt: jwt.NewNumericDate(time.Now()),
+			ID:        NewTokenID(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessTokenClaims)
-
-	accessTokenString, err := accessToken.SignedString([]byte(accessTokenSecret))
+	accessTokenString, err := accessTokenSigner.Sign(accessTokenClaims)
 	if err != nil {
 		return "", "", err
 	}
 
 	refreshTokenClaims := &jwt.RegisteredClaims{
-		Subject: user.ID,
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
+		ID:        refreshTokenID,
+		Subject:   user.ID,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenTTL)),
 		IssuedAt:  jwt.NewNumericDate(time.Now()),
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshTokenClaims)
-
-	refreshTokenString, err := refreshToken.SignedString([]byte(refreshTokenSecret))
+	refreshTokenString, err := refreshTokenSigner.Sign(refreshTokenClaims)
 	if err != nil {
 		return "", "", err
 	}
 
 	return accessTokenString, refreshTokenString, nil
 }
+
+// NewTokenID returns a random identifier suitable for a JWT "jti" claim.
+func NewTokenID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// NewSecretToken returns a random single-use token to hand to the user
+// together with the hash to store in its place.
+func NewSecretToken() (token string, hash string) {
+	b := make([]byte, 32)
+	rand.Read(b)
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashSecretToken(token)
+}
+
+func HashSecretToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
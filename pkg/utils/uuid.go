@@ -0,0 +1,23 @@
+package utils
+
+// IsValidUUID reports whether s is a UUID in its canonical hyphenated form,
+// such as "3f1c2a9e-8d4b-4c1e-9a7f-2b6d5e8c0a14". IDs are checked before they
+// reach Postgres, which rejects anything else with an error.
+func IsValidUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, c := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}